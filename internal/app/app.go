@@ -1,22 +1,139 @@
 package app
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"event-service/internal/archive"
+	"event-service/internal/model"
+	"event-service/internal/store"
+	"event-service/internal/worker"
+	"fmt"
+	"io"
 	"log"
+	"net"
 	"net/http"
+	"net/url"
 	"os"
+	"slices"
+	"sort"
 	"strconv"
-	"event-service/internal/model"
-	"event-service/internal/store"
-	"event-service/internal/worker"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 )
 
 // Config holds the application configuration
 type Config struct {
 	Port              string
+	AdminPort         string
 	Env               string
-	ProcessingDelayMs int
+	BasePath          string
+	APIKey            string `secret:"true"`
+	RequestTimeoutMs  int
+	BodyReadTimeoutMs int
+	ShutdownTimeoutMs int
+
+	CORSAllowedOrigins []string
+
+	RateLimitRPS   float64
+	RateLimitBurst int
+	TrustProxy     bool
+
+	ProcessingDelayMs        int
+	QueueOrder               string
+	QueueCapacity            int
+	WorkerConcurrency        int
+	MaxInflight              int
+	OrderedCommit            bool
+	AutoShutdownIdleMs       int
+	MaxInflightBytes         int
+	QueueSaturationThreshold float64
+	HealthDegradedThreshold  float64
+	ReadyMaxErrorRate        float64
+	ErrorRateWindowMs        int
+	StatsWindow              int
+	ErrorRateMinAttempts     int
+
+	MaxEventIDLength       int
+	EventIDWhitespace      string
+	WorkerMode             string
+	ShutdownHandoff        bool
+	AllowGeneratedIDs      bool
+	MaxPayloadBytes        int
+	MaxBatchSize           int
+	MaxPayloadFields       int
+	MaxPayloadFieldsNested bool
+	DedupKeyPaths          []string
+	DuplicatePolicy        string
+	CoalesceWindowMs       int
+	CoalesceMerge          string
+
+	StoreRetryAttempts  int
+	StoreRetryBackoffMs int
+	StoreRetryRequeue   bool
+
+	ProcessRatePerSec int
+
+	MaxRetries        int
+	RetryBackoffMs    int
+	RetryBackoffMaxMs int
+
+	EnrichmentURL         string
+	EnrichmentKeyField    string
+	EnrichmentTargetField string
+	EnrichmentTimeoutMs   int
+	EnrichmentCacheTTLMs  int
+	EnrichmentFatal       bool
+
+	CallbackTimeoutMs   int
+	CallbackMaxAttempts int
+	CallbackBackoffMs   int
+
+	IdempotencyServiceURL    string
+	IdempotencyTimeoutMs     int
+	IdempotencyFailurePolicy string
+	IdempotencyTTLMs         int
+
+	ProcessingTimeoutMs       int
+	ProcessingTimeoutByTypeMs map[string]int
+	CheckpointsEnabled        bool
+
+	StoreBackend           string
+	StoreDSN               string `secret:"true"`
+	RedisAddr              string
+	RedisPrefix            string
+	RedisUsername          string
+	RedisPassword          string `secret:"true"`
+	RedisTLS               bool
+	IndexedFields          []string
+	StoreMaxEvents         int
+	ReadSnapshotIntervalMs int
+	ListOrder              string
+
+	ArchiveS3Endpoint        string
+	ArchiveS3Bucket          string
+	ArchiveS3Region          string
+	ArchiveS3AccessKeyID     string `secret:"true"`
+	ArchiveS3SecretAccessKey string `secret:"true"`
+	ArchivePrefix            string
+	ArchiveIntervalMs        int
+	ArchiveMaxObjectBytes    int
+	ArchiveTimeoutMs         int
+
+	ErrorMessagesFile string
+	DefaultLocale     string
+
+	MetricsEnabled bool
+	OpenAPIEnabled bool
+	OTelEndpoint   string
+
+	// invalidEnv lists the variables LoadConfig could not parse, reported
+	// by Validate
+	invalidEnv []string
 }
 
 // App represents the HTTP application
@@ -24,153 +141,1360 @@ type App struct {
 	config    Config
 	store     *store.Store
 	worker    *worker.Worker
+	exporter  *archive.Exporter
 	startTime time.Time
 	server    *http.Server
+	frontend  string
+	messages  *messageCatalog
+	idle      chan struct{}
+	// metrics is nil unless METRICS_ENABLED is set
+	metrics *metrics
+	// openAPI is the rendered spec, nil when OPENAPI_ENABLED is off
+	openAPI []byte
+	// coalescer merges same-key events when COALESCE_WINDOW_MS is set
+	coalescer *coalescer
+	// limiter is nil unless RATE_LIMIT_RPS is set
+	limiter *rateLimiter
+	// tracerProvider exports spans; nil unless OTEL_EXPORTER_OTLP_ENDPOINT is set
+	tracerProvider *sdktrace.TracerProvider
+
+	// adminServer serves operational endpoints when ADMIN_PORT is set
+	adminServer *http.Server
+
+	// draining is set once shutdown begins; submissions tracks POST /events
+	// requests that passed the draining check and must complete first
+	mu          sync.Mutex
+	draining    bool
+	submissions sync.WaitGroup
+
+	// Lifetime counters reported in the shutdown summary
+	accepted   atomic.Int64
+	duplicates atomic.Int64
 }
 
-// LoadConfig loads configuration from environment variables with defaults
+// LoadConfig loads configuration from environment variables with defaults.
+// With CONFIG_FILE set, settings missing from the environment are taken
+// from that file before falling back to the defaults.
 func LoadConfig() Config {
-	port := getEnv("PORT", "8080")
-	env := getEnv("ENV", "dev")
-	processingDelayMs := getEnvAsInt("PROCESSING_DELAY_MS", 1000)
+	var src envSource
+	if path := os.Getenv("CONFIG_FILE"); path != "" {
+		file, err := loadConfigFile(path)
+		if err != nil {
+			src.invalid = append(src.invalid, fmt.Sprintf("CONFIG_FILE %s: %v", path, err))
+		}
+		src.file = file
+	}
+	port := src.getEnv("PORT", "8080")
+	adminPort := src.getEnv("ADMIN_PORT", "")
+	env := src.getEnv("ENV", "dev")
+	basePath := src.getEnv("BASE_PATH", "")
+	apiKey := src.getEnv("API_KEY", "")
+	corsAllowedOrigins := src.getEnvAsList("CORS_ALLOWED_ORIGINS", nil)
+	rateLimitRPS := src.getEnvAsFloat("RATE_LIMIT_RPS", 0)
+	rateLimitBurst := src.getEnvAsInt("RATE_LIMIT_BURST", 0)
+	trustProxy := src.getEnvAsBool("TRUST_PROXY", false)
+	requestTimeoutMs := src.getEnvAsInt("REQUEST_TIMEOUT_MS", 0)
+	bodyReadTimeoutMs := src.getEnvAsInt("BODY_READ_TIMEOUT_MS", 10000)
+	shutdownTimeoutMs := src.getEnvAsInt("SHUTDOWN_TIMEOUT_MS", 10000)
+	processingDelayMs := src.getEnvAsInt("PROCESSING_DELAY_MS", 1000)
+	queueOrder := src.getEnv("QUEUE_ORDER", "fifo")
+	queueCapacity := src.getEnvAsInt("QUEUE_CAPACITY", worker.DefaultQueueCapacity)
+	workerConcurrency := src.getEnvAsInt("WORKER_CONCURRENCY", 1)
+	maxInflight := src.getEnvAsInt("MAX_INFLIGHT", 0)
+	orderedCommit := src.getEnvAsBool("ORDERED_COMMIT", false)
+	autoShutdownIdleMs := src.getEnvAsInt("AUTO_SHUTDOWN_IDLE_MS", 0)
+	maxInflightBytes := src.getEnvAsInt("MAX_INFLIGHT_BYTES", 0)
+	queueSaturationThreshold := src.getEnvAsFloat("QUEUE_SATURATION_THRESHOLD", 0.5)
+	healthDegradedThreshold := src.getEnvAsFloat("HEALTH_DEGRADED_THRESHOLD", 0.9)
+	readyMaxErrorRate := src.getEnvAsFloat("READY_MAX_ERROR_RATE", 0)
+	errorRateWindowMs := src.getEnvAsInt("ERROR_RATE_WINDOW_MS", 60000)
+	statsWindow := src.getEnvAsInt("STATS_WINDOW", worker.DefaultStatsWindow)
+	errorRateMinAttempts := src.getEnvAsInt("ERROR_RATE_MIN_ATTEMPTS", 10)
+	maxEventIDLength := src.getEnvAsInt("MAX_EVENT_ID_LENGTH", 256)
+	eventIDWhitespace := src.getEnv("EVENT_ID_WHITESPACE", "reject")
+	workerMode := src.getEnv("WORKER_MODE", "auto")
+	shutdownHandoff := src.getEnvAsBool("SHUTDOWN_HANDOFF", false)
+	allowGeneratedIDs := src.getEnvAsBool("ALLOW_GENERATED_IDS", false)
+	maxPayloadBytes := src.getEnvAsInt("MAX_PAYLOAD_BYTES", 1<<20)
+	maxBatchSize := src.getEnvAsInt("MAX_BATCH_SIZE", 500)
+	maxPayloadFields := src.getEnvAsInt("MAX_PAYLOAD_FIELDS", 10000)
+	maxPayloadFieldsNested := src.getEnvAsBool("MAX_PAYLOAD_FIELDS_NESTED", false)
+	dedupKeyPaths := src.getEnvAsList("DEDUP_KEY_PATHS", nil)
+	duplicatePolicy := src.getEnv("DUPLICATE_POLICY", duplicateReject)
+	coalesceWindowMs := src.getEnvAsInt("COALESCE_WINDOW_MS", 0)
+	coalesceMerge := src.getEnv("COALESCE_MERGE", "first")
+	storeRetryAttempts := src.getEnvAsInt("STORE_RETRY_ATTEMPTS", 3)
+	storeRetryBackoffMs := src.getEnvAsInt("STORE_RETRY_BACKOFF_MS", 100)
+	storeRetryRequeue := src.getEnvAsBool("STORE_RETRY_REQUEUE", false)
+	processRatePerSec := src.getEnvAsInt("PROCESS_RATE_PER_SEC", 0)
+	maxRetries := src.getEnvAsInt("MAX_RETRIES", 3)
+	retryBackoffMs := src.getEnvAsInt("RETRY_BACKOFF_MS", 1000)
+	retryBackoffMaxMs := src.getEnvAsInt("RETRY_BACKOFF_MAX_MS", 30000)
+	enrichmentURL := src.getEnv("ENRICHMENT_URL", "")
+	enrichmentKeyField := src.getEnv("ENRICHMENT_KEY_FIELD", "user_id")
+	enrichmentTargetField := src.getEnv("ENRICHMENT_TARGET_FIELD", "enrichment")
+	enrichmentTimeoutMs := src.getEnvAsInt("ENRICHMENT_TIMEOUT_MS", 1000)
+	enrichmentCacheTTLMs := src.getEnvAsInt("ENRICHMENT_CACHE_TTL_MS", 60000)
+	enrichmentFatal := src.getEnvAsBool("ENRICHMENT_FATAL", false)
+	callbackTimeoutMs := src.getEnvAsInt("CALLBACK_TIMEOUT_MS", 5000)
+	callbackMaxAttempts := src.getEnvAsInt("CALLBACK_MAX_ATTEMPTS", worker.DefaultCallbackMaxAttempts)
+	callbackBackoffMs := src.getEnvAsInt("CALLBACK_BACKOFF_MS", 1000)
+	idempotencyServiceURL := src.getEnv("IDEMPOTENCY_SERVICE_URL", "")
+	idempotencyTimeoutMs := src.getEnvAsInt("IDEMPOTENCY_TIMEOUT_MS", 500)
+	idempotencyFailurePolicy := src.getEnv("IDEMPOTENCY_FAILURE_POLICY", "closed")
+	idempotencyTTLMs := src.getEnvAsInt("IDEMPOTENCY_TTL_MS", 0)
+	processingTimeoutMs := src.getEnvAsInt("PROCESSING_TIMEOUT_MS", 0)
+	processingTimeoutByTypeMs := src.getEnvAsIntMap("PROCESSING_TIMEOUT_BY_TYPE", nil)
+	checkpointsEnabled := src.getEnvAsBool("CHECKPOINTS_ENABLED", true)
+	storeBackend := src.getEnv("STORE_BACKEND", "memory")
+	storeDSN := src.getEnv("STORE_DSN", "events.db")
+	redisAddr := src.getEnv("REDIS_ADDR", "localhost:6379")
+	redisPrefix := src.getEnv("REDIS_PREFIX", "event-service")
+	redisUsername := src.getEnv("REDIS_USERNAME", "")
+	redisPassword := src.getEnv("REDIS_PASSWORD", "")
+	redisTLS := src.getEnvAsBool("REDIS_TLS", false)
+	indexedFields := src.getEnvAsList("INDEXED_FIELDS", nil)
+	storeMaxEvents := src.getEnvAsInt("STORE_MAX_EVENTS", 0)
+	readSnapshotIntervalMs := src.getEnvAsInt("READ_SNAPSHOT_INTERVAL_MS", 0)
+	listOrder := src.getEnv("LIST_ORDER", "oldest")
+	archiveS3Endpoint := src.getEnv("ARCHIVE_S3_ENDPOINT", "")
+	archiveS3Bucket := src.getEnv("ARCHIVE_S3_BUCKET", "")
+	archiveS3Region := src.getEnv("ARCHIVE_S3_REGION", "us-east-1")
+	archiveS3AccessKeyID := src.getEnv("ARCHIVE_S3_ACCESS_KEY_ID", "")
+	archiveS3SecretAccessKey := src.getEnv("ARCHIVE_S3_SECRET_ACCESS_KEY", "")
+	archivePrefix := src.getEnv("ARCHIVE_PREFIX", "events/")
+	archiveIntervalMs := src.getEnvAsInt("ARCHIVE_INTERVAL_MS", 60000)
+	archiveMaxObjectBytes := src.getEnvAsInt("ARCHIVE_MAX_OBJECT_BYTES", 8388608)
+	archiveTimeoutMs := src.getEnvAsInt("ARCHIVE_TIMEOUT_MS", 10000)
+	errorMessagesFile := src.getEnv("ERROR_MESSAGES_FILE", "")
+	defaultLocale := src.getEnv("DEFAULT_LOCALE", "en")
+	metricsEnabled := src.getEnvAsBool("METRICS_ENABLED", false)
+	openAPIEnabled := src.getEnvAsBool("OPENAPI_ENABLED", true)
+	otelEndpoint := src.getEnv("OTEL_EXPORTER_OTLP_ENDPOINT", "")
 
 	return Config{
 		Port:              port,
+		AdminPort:         adminPort,
 		Env:               env,
-		ProcessingDelayMs: processingDelayMs,
+		BasePath:          basePath,
+		APIKey:            apiKey,
+		RequestTimeoutMs:  requestTimeoutMs,
+		BodyReadTimeoutMs: bodyReadTimeoutMs,
+		ShutdownTimeoutMs: shutdownTimeoutMs,
+
+		CORSAllowedOrigins: corsAllowedOrigins,
+
+		RateLimitRPS:   rateLimitRPS,
+		RateLimitBurst: rateLimitBurst,
+		TrustProxy:     trustProxy,
+
+		ProcessingDelayMs:        processingDelayMs,
+		QueueOrder:               queueOrder,
+		QueueCapacity:            queueCapacity,
+		WorkerConcurrency:        workerConcurrency,
+		MaxInflight:              maxInflight,
+		OrderedCommit:            orderedCommit,
+		AutoShutdownIdleMs:       autoShutdownIdleMs,
+		MaxInflightBytes:         maxInflightBytes,
+		QueueSaturationThreshold: queueSaturationThreshold,
+		HealthDegradedThreshold:  healthDegradedThreshold,
+		ReadyMaxErrorRate:        readyMaxErrorRate,
+		ErrorRateWindowMs:        errorRateWindowMs,
+		StatsWindow:              statsWindow,
+		ErrorRateMinAttempts:     errorRateMinAttempts,
+
+		MaxEventIDLength:       maxEventIDLength,
+		EventIDWhitespace:      eventIDWhitespace,
+		WorkerMode:             workerMode,
+		ShutdownHandoff:        shutdownHandoff,
+		AllowGeneratedIDs:      allowGeneratedIDs,
+		MaxPayloadBytes:        maxPayloadBytes,
+		MaxBatchSize:           maxBatchSize,
+		MaxPayloadFields:       maxPayloadFields,
+		MaxPayloadFieldsNested: maxPayloadFieldsNested,
+		DedupKeyPaths:          dedupKeyPaths,
+		CoalesceWindowMs:       coalesceWindowMs,
+		CoalesceMerge:          coalesceMerge,
+		DuplicatePolicy:        duplicatePolicy,
+
+		StoreRetryAttempts:  storeRetryAttempts,
+		StoreRetryBackoffMs: storeRetryBackoffMs,
+		StoreRetryRequeue:   storeRetryRequeue,
+
+		ProcessRatePerSec: processRatePerSec,
+
+		MaxRetries:        maxRetries,
+		RetryBackoffMs:    retryBackoffMs,
+		RetryBackoffMaxMs: retryBackoffMaxMs,
+
+		EnrichmentURL:         enrichmentURL,
+		EnrichmentKeyField:    enrichmentKeyField,
+		EnrichmentTargetField: enrichmentTargetField,
+		EnrichmentTimeoutMs:   enrichmentTimeoutMs,
+		EnrichmentCacheTTLMs:  enrichmentCacheTTLMs,
+		EnrichmentFatal:       enrichmentFatal,
+
+		CallbackTimeoutMs:   callbackTimeoutMs,
+		CallbackMaxAttempts: callbackMaxAttempts,
+		CallbackBackoffMs:   callbackBackoffMs,
+
+		IdempotencyServiceURL:    idempotencyServiceURL,
+		IdempotencyTimeoutMs:     idempotencyTimeoutMs,
+		IdempotencyFailurePolicy: idempotencyFailurePolicy,
+		IdempotencyTTLMs:         idempotencyTTLMs,
+
+		ProcessingTimeoutMs:       processingTimeoutMs,
+		ProcessingTimeoutByTypeMs: processingTimeoutByTypeMs,
+		CheckpointsEnabled:        checkpointsEnabled,
+
+		StoreBackend:           storeBackend,
+		StoreDSN:               storeDSN,
+		RedisAddr:              redisAddr,
+		RedisPrefix:            redisPrefix,
+		RedisUsername:          redisUsername,
+		RedisPassword:          redisPassword,
+		RedisTLS:               redisTLS,
+		IndexedFields:          indexedFields,
+		StoreMaxEvents:         storeMaxEvents,
+		ReadSnapshotIntervalMs: readSnapshotIntervalMs,
+		ListOrder:              listOrder,
+
+		ArchiveS3Endpoint:        archiveS3Endpoint,
+		ArchiveS3Bucket:          archiveS3Bucket,
+		ArchiveS3Region:          archiveS3Region,
+		ArchiveS3AccessKeyID:     archiveS3AccessKeyID,
+		ArchiveS3SecretAccessKey: archiveS3SecretAccessKey,
+		ArchivePrefix:            archivePrefix,
+		ArchiveIntervalMs:        archiveIntervalMs,
+		ArchiveMaxObjectBytes:    archiveMaxObjectBytes,
+		ArchiveTimeoutMs:         archiveTimeoutMs,
+
+		ErrorMessagesFile: errorMessagesFile,
+		DefaultLocale:     defaultLocale,
+
+		MetricsEnabled: metricsEnabled,
+		OpenAPIEnabled: openAPIEnabled,
+		OTelEndpoint:   otelEndpoint,
+
+		invalidEnv: src.problems(),
+	}
+}
+
+// openBackend opens the persistent backend named by STORE_BACKEND, or
+// returns nil to keep events in memory only
+func openBackend(config Config) (store.Backend, error) {
+	switch config.StoreBackend {
+	case "", "memory":
+		return nil, nil
+	case "sqlite":
+		backend, err := store.OpenSQLite(config.StoreDSN)
+		if err != nil {
+			return nil, fmt.Errorf("open SQLite store at %s: %w", config.StoreDSN, err)
+		}
+		log.Printf("Persisting events to SQLite at %s", config.StoreDSN)
+		return backend, nil
+	case "redis":
+		// Claiming event IDs in Redis keeps replicas sharing the prefix from
+		// accepting the same event twice
+		backend, err := store.OpenRedis(store.RedisConfig{
+			Addr:     config.RedisAddr,
+			Prefix:   config.RedisPrefix,
+			Username: config.RedisUsername,
+			Password: config.RedisPassword,
+			TLS:      config.RedisTLS,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("connect to Redis at %s: %w", config.RedisAddr, err)
+		}
+		log.Printf("Persisting events to Redis at %s (prefix %q)", config.RedisAddr, config.RedisPrefix)
+		return backend, nil
 	}
+	return nil, fmt.Errorf("unknown store backend %q", config.StoreBackend)
 }
 
-// New creates a new application instance
-func New(config Config) *App {
+// New creates a new application instance. It fails if the configured store
+// backend can't be opened, rather than silently losing events on restart.
+func New(config Config) (*App, error) {
+	config.BasePath = normalizeBasePath(config.BasePath)
 	st := store.New()
-	wkr := worker.New(st, config.ProcessingDelayMs)
+	if config.IdempotencyServiceURL != "" {
+		remote := store.NewIdempotencyService(
+			config.IdempotencyServiceURL,
+			time.Duration(config.IdempotencyTimeoutMs)*time.Millisecond,
+			store.FailurePolicy(config.IdempotencyFailurePolicy),
+		)
+		st = store.NewWithIdempotencyService(remote)
+		log.Printf("Using idempotency service at %s (failure policy: %s)", config.IdempotencyServiceURL, config.IdempotencyFailurePolicy)
+	}
+	st.SetListOrder(store.ListOrder(config.ListOrder))
+	st.SetIndexedFields(config.IndexedFields)
+	st.SetMaxEvents(config.StoreMaxEvents)
+	backend, err := openBackend(config)
+	if err != nil {
+		return nil, err
+	}
+	if backend != nil {
+		if err := st.UseBackend(backend); err != nil {
+			backend.Close()
+			return nil, fmt.Errorf("load persisted events: %w", err)
+		}
+	}
+	st.EnableIdempotencyTTL(time.Duration(config.IdempotencyTTLMs) * time.Millisecond)
+	st.EnableReadSnapshots(time.Duration(config.ReadSnapshotIntervalMs) * time.Millisecond)
+	var enrichment *worker.EnrichmentConfig
+	if config.EnrichmentURL != "" {
+		enrichment = &worker.EnrichmentConfig{
+			URL:         config.EnrichmentURL,
+			KeyField:    config.EnrichmentKeyField,
+			TargetField: config.EnrichmentTargetField,
+			Timeout:     time.Duration(config.EnrichmentTimeoutMs) * time.Millisecond,
+			CacheTTL:    time.Duration(config.EnrichmentCacheTTLMs) * time.Millisecond,
+			Fatal:       config.EnrichmentFatal,
+		}
+	}
+	wkr := worker.New(st, worker.Config{
+		ProcessingDelayMs: config.ProcessingDelayMs,
+		QueueOrder:        worker.QueueOrder(config.QueueOrder),
+		QueueCapacity:     config.QueueCapacity,
+		Mode:              worker.Mode(config.WorkerMode),
+		Concurrency:       config.WorkerConcurrency,
+		MaxInflight:       config.MaxInflight,
+		OrderedCommit:     config.OrderedCommit,
+		ErrorRateWindow:   time.Duration(config.ErrorRateWindowMs) * time.Millisecond,
+		StatsWindow:       config.StatsWindow,
+		ShutdownHandoff:   config.ShutdownHandoff,
+
+		StoreRetryAttempts:  config.StoreRetryAttempts,
+		StoreRetryBackoffMs: config.StoreRetryBackoffMs,
+		StoreRetryRequeue:   config.StoreRetryRequeue,
+
+		MaxRetries:        config.MaxRetries,
+		RetryBackoffMs:    config.RetryBackoffMs,
+		RetryBackoffMaxMs: config.RetryBackoffMaxMs,
+
+		ProcessRatePerSec: config.ProcessRatePerSec,
+		MaxInflightBytes:  int64(config.MaxInflightBytes),
+
+		Enrichment: enrichment,
+
+		Callbacks: worker.CallbackConfig{
+			Timeout:     time.Duration(config.CallbackTimeoutMs) * time.Millisecond,
+			MaxAttempts: config.CallbackMaxAttempts,
+			Backoff:     time.Duration(config.CallbackBackoffMs) * time.Millisecond,
+		},
+
+		ProcessingTimeout:       time.Duration(config.ProcessingTimeoutMs) * time.Millisecond,
+		ProcessingTimeoutByType: msDurations(config.ProcessingTimeoutByTypeMs),
+
+		Checkpoints: config.CheckpointsEnabled,
+	})
+
+	messages, err := loadMessageCatalog(config.ErrorMessagesFile, config.DefaultLocale)
+	if err != nil {
+		log.Printf("Failed to load error messages, using English defaults: %v", err)
+	}
 
-	return &App{
+	var exporter *archive.Exporter
+	if config.ArchiveS3Endpoint != "" {
+		storage := archive.NewS3Storage(archive.S3Config{
+			Endpoint:        config.ArchiveS3Endpoint,
+			Bucket:          config.ArchiveS3Bucket,
+			Region:          config.ArchiveS3Region,
+			AccessKeyID:     config.ArchiveS3AccessKeyID,
+			SecretAccessKey: config.ArchiveS3SecretAccessKey,
+		})
+		exporter = archive.New(st, storage, archive.Config{
+			Prefix:         config.ArchivePrefix,
+			Interval:       time.Duration(config.ArchiveIntervalMs) * time.Millisecond,
+			MaxObjectBytes: config.ArchiveMaxObjectBytes,
+			Timeout:        time.Duration(config.ArchiveTimeoutMs) * time.Millisecond,
+		})
+	}
+
+	a := &App{
 		config:    config,
 		store:     st,
 		worker:    wkr,
+		exporter:  exporter,
 		startTime: time.Now(),
+		frontend:  renderFrontend(config.BasePath),
+		messages:  messages,
+		idle:      make(chan struct{}),
+	}
+	if config.MetricsEnabled {
+		a.metrics = newMetrics(a)
+		wkr.Use(a.metrics.middleware)
 	}
+	if a.config.DuplicatePolicy == "" {
+		a.config.DuplicatePolicy = duplicateReject
+	} else if a.config.DuplicatePolicy != duplicateReject && a.config.DuplicatePolicy != duplicateCompare {
+		log.Printf("Unknown duplicate policy %q, using default: %s", a.config.DuplicatePolicy, duplicateReject)
+		a.config.DuplicatePolicy = duplicateReject
+	}
+	if a.config.EventIDWhitespace == "" {
+		a.config.EventIDWhitespace = "reject"
+	} else if !slices.Contains(knownEventIDWhitespace, a.config.EventIDWhitespace) {
+		log.Printf("Unknown event_id whitespace handling %q, using default: reject", a.config.EventIDWhitespace)
+		a.config.EventIDWhitespace = "reject"
+	}
+	if config.CoalesceWindowMs > 0 {
+		a.coalescer = newCoalescer(st, wkr.EnqueueWait, time.Duration(config.CoalesceWindowMs)*time.Millisecond, config.CoalesceMerge)
+	}
+	if config.RateLimitRPS > 0 {
+		a.limiter = newRateLimiter(config.RateLimitRPS, config.RateLimitBurst)
+	}
+	if config.OTelEndpoint != "" {
+		if a.tracerProvider, err = newTracerProvider(config.OTelEndpoint); err != nil {
+			log.Printf("Failed to set up tracing, continuing without: %v", err)
+		} else {
+			log.Printf("Exporting traces to %s", config.OTelEndpoint)
+		}
+	}
+	if config.OpenAPIEnabled {
+		if a.openAPI, err = renderOpenAPI(config); err != nil {
+			log.Printf("Failed to render OpenAPI spec, not serving it: %v", err)
+		}
+	}
+	return a, nil
 }
 
-// Start starts the HTTP server and background worker
+// Start starts the HTTP server and background worker. It returns an error
+// straight away if ADMIN_PORT can't be bound, before anything is started.
 func (a *App) Start() error {
+	var adminListener net.Listener
+	if a.config.AdminPort != "" {
+		var err error
+		if adminListener, err = net.Listen("tcp", ":"+a.config.AdminPort); err != nil {
+			return fmt.Errorf("admin server: %w", err)
+		}
+	}
+
 	a.worker.Start()
+	// Re-enqueue events a previous instance handed off; done in the background
+	// so a large backlog doesn't delay the server coming up
+	go a.worker.Recover()
+	if a.exporter != nil {
+		a.exporter.Start()
+	}
+	if a.config.AutoShutdownIdleMs > 0 {
+		go a.watchIdle(time.Duration(a.config.AutoShutdownIdleMs) * time.Millisecond)
+	}
 
+	a.server = &http.Server{
+		Addr:    ":" + a.config.Port,
+		Handler: a.routes(),
+	}
+	if adminListener != nil {
+		a.adminServer = &http.Server{
+			Addr:    ":" + a.config.AdminPort,
+			Handler: a.adminRoutes(),
+		}
+		go func() {
+			log.Printf("Starting admin server on port %s", a.config.AdminPort)
+			if err := a.adminServer.Serve(adminListener); err != nil && err != http.ErrServerClosed {
+				log.Printf("Admin server error: %v", err)
+			}
+		}()
+	}
+
+	log.Printf("Starting server on port %s (env: %s, base path: %q)", a.config.Port, a.config.Env, a.config.BasePath)
+	return a.server.ListenAndServe()
+}
+
+// routes registers all handlers, mounted under the configured base path
+func (a *App) routes() http.Handler {
 	mux := http.NewServeMux()
-	mux.HandleFunc("/events", a.handleEvents)
+	// CORS goes first so preflight requests don't need the API key
+	mux.HandleFunc("/events", a.withCORS(a.withRateLimit(a.withAPIKey(a.handleEvents))))
+	mux.HandleFunc("/events/", a.withCORS(a.withRateLimit(a.withAPIKey(a.handleEventRoutes))))
+	mux.HandleFunc("/events/validate", a.withCORS(a.handleValidate))
+	mux.HandleFunc("/events/batch", a.withCORS(a.withRateLimit(a.withAPIKey(a.handleBatch))))
+	mux.HandleFunc("/events/count", a.withCORS(a.handleEventCounts))
+	mux.HandleFunc("/events/stats", a.withCORS(a.handleEventStats))
+	mux.HandleFunc("/stats/sla", a.handleSLAStats)
+	// The dashboard polls health and readiness, so they stay on the main
+	// port even when operational endpoints move to the admin port
 	mux.HandleFunc("/health", a.handleHealth)
 	mux.HandleFunc("/ready", a.handleReady)
+	if a.openAPI != nil {
+		mux.HandleFunc("/openapi.json", a.handleOpenAPI)
+	}
+	if a.config.AdminPort == "" {
+		a.registerAdminRoutes(mux)
+	}
 	mux.HandleFunc("/", a.handleFrontend)
 
-	a.server = &http.Server{
-		Addr:    ":" + a.config.Port,
-		Handler: mux,
+	var handler http.Handler = mux
+	if a.config.BasePath != "" {
+		// Handlers see paths relative to the base path, so none of them need
+		// to know about the prefix
+		root := http.NewServeMux()
+		root.Handle(a.config.BasePath+"/", http.StripPrefix(a.config.BasePath, mux))
+		root.Handle(a.config.BasePath, http.RedirectHandler(a.config.BasePath+"/", http.StatusMovedPermanently))
+		handler = root
 	}
 
-	log.Printf("Starting server on port %s (env: %s)", a.config.Port, a.config.Env)
-	return a.server.ListenAndServe()
+	return withRequestID(withTracing(a.withRequestTimeout(handler)))
+}
+
+// adminRoutes serves the operational endpoints on the separate ADMIN_PORT
+// listener. It is not mounted under the base path.
+func (a *App) adminRoutes() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/health", a.handleHealth)
+	mux.HandleFunc("/ready", a.handleReady)
+	a.registerAdminRoutes(mux)
+	return withRequestID(a.withRequestTimeout(mux))
+}
+
+// registerAdminRoutes adds the admin and debug endpoints to mux. With
+// API_KEY set they all require it, on the admin port as well.
+func (a *App) registerAdminRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("/admin/tick", a.withAdminAPIKey(a.handleTick))
+	mux.HandleFunc("/admin/pause", a.withAdminAPIKey(a.handlePauseType))
+	mux.HandleFunc("/admin/resume", a.withAdminAPIKey(a.handleResumeType))
+	mux.HandleFunc("/admin/config", a.withAdminAPIKey(a.handleConfig))
+	mux.HandleFunc("/debug/paused", a.withAdminAPIKey(a.handlePausedTypes))
+	if a.metrics != nil {
+		mux.HandleFunc("/metrics", a.withAdminAPIKey(a.metrics.handler().ServeHTTP))
+	}
+}
+
+// withRequestTimeout applies REQUEST_TIMEOUT_MS. There are no streaming
+// endpoints yet, so every route gets the limit; future long-lived routes
+// must be registered outside this handler. With BODY_READ_TIMEOUT_MS the
+// body is read first, under its own deadline (see readBodyAhead).
+func (a *App) withRequestTimeout(handler http.Handler) http.Handler {
+	if a.config.RequestTimeoutMs <= 0 {
+		return handler
+	}
+	timeout := http.TimeoutHandler(handler, time.Duration(a.config.RequestTimeoutMs)*time.Millisecond, `{"code":"request_timeout","message":"Request timed out"}`)
+	if a.config.BodyReadTimeoutMs <= 0 {
+		return timeout
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if a.readBodyAhead(w, r) {
+			timeout.ServeHTTP(w, r)
+		}
+	})
 }
 
-// Shutdown gracefully shuts down the application
-func (a *App) Shutdown() {
+// Shutdown gracefully shuts down the application. The worker drain and the
+// HTTP servers share the deadline of ctx; connections still open when it
+// passes are closed.
+func (a *App) Shutdown(ctx context.Context) {
 	log.Println("Shutting down application...")
-	a.worker.Stop()
-	if a.server != nil {
-		a.server.Close()
+
+	// Reject new submissions, then let those already past the draining
+	// check finish enqueueing before the worker drains its queue
+	a.mu.Lock()
+	a.draining = true
+	a.mu.Unlock()
+	a.submissions.Wait()
+
+	if a.coalescer != nil {
+		// Events still waiting for merges are processed in the drain
+		a.coalescer.flushAll()
+	}
+	a.worker.Stop(ctx)
+	// Before the store closes, so no request changes an event it can no
+	// longer persist
+	shutdownServer(ctx, a.server)
+	shutdownServer(ctx, a.adminServer)
+	if a.exporter != nil {
+		// Runs after the drain so events processed during it are archived too
+		a.exporter.Stop()
+	}
+	a.store.Close()
+	if a.tracerProvider != nil {
+		// Last, so the spans of the drain are exported too
+		if err := a.tracerProvider.Shutdown(ctx); err != nil {
+			log.Printf("Failed to flush traces: %v", err)
+		}
+	}
+	a.logSummary()
+}
+
+// shutdownServer lets in-flight requests finish until ctx is done, then
+// closes any connections still open
+func shutdownServer(ctx context.Context, server *http.Server) {
+	if server == nil {
+		return
+	}
+	if err := server.Shutdown(ctx); err != nil {
+		log.Printf("Server shutdown incomplete, closing remaining connections: %v", err)
+		server.Close()
 	}
 }
 
+// Idle is closed once the service has been idle for AUTO_SHUTDOWN_IDLE_MS,
+// signalling that it can be shut down. It never closes when that is unset.
+func (a *App) Idle() <-chan struct{} {
+	return a.idle
+}
+
+// watchIdle closes a.idle once the worker has had no work for the given
+// period, and gives up if the application starts draining first
+func (a *App) watchIdle(period time.Duration) {
+	ticker := time.NewTicker(period / 4)
+	defer ticker.Stop()
+	for range ticker.C {
+		a.mu.Lock()
+		draining := a.draining
+		a.mu.Unlock()
+		if draining {
+			return
+		}
+		if a.worker.IdleFor() >= period {
+			log.Printf("No events for %s, requesting shutdown", period)
+			close(a.idle)
+			return
+		}
+	}
+}
+
+// logSummary emits lifetime stats of this instance as a single JSON log line
+func (a *App) logSummary() {
+	stats := a.worker.Stats()
+	summary := model.ServiceSummary{
+		Msg:             "service.summary",
+		Accepted:        a.accepted.Load(),
+		Processed:       stats.Processed,
+		Failed:          stats.Failed,
+		Duplicates:      a.duplicates.Load(),
+		AvgProcessingMs: float64(stats.AvgProcessingTime) / float64(time.Millisecond),
+		PeakQueueDepth:  stats.PeakQueueDepth,
+		Uptime:          time.Since(a.startTime).String(),
+	}
+	line, err := json.Marshal(summary)
+	if err != nil {
+		log.Printf("Failed to encode service summary: %v", err)
+		return
+	}
+	log.Println(string(line))
+}
+
 // handleEvents handles POST /events (create) and GET /events (list)
 func (a *App) handleEvents(w http.ResponseWriter, r *http.Request) {
 	if r.Method == http.MethodGet {
-		// List all events
-		events := a.store.List()
-		response := make([]model.EventResponse, len(events))
-		for i, event := range events {
-			response[i] = model.EventResponse{
-				EventID: event.EventID,
-				Payload: event.Payload,
-				Status:  event.Status,
+		if modifiedAfter := r.URL.Query().Get("modified_after"); modifiedAfter != "" {
+			a.handleEventsSync(w, r, modifiedAfter)
+			return
+		}
+		if err := listFormat(r); err != nil {
+			a.writeError(w, r, http.StatusBadRequest, err)
+			return
+		}
+
+		if correlationID := r.URL.Query().Get("correlation_id"); correlationID != "" {
+			events := a.store.ListByCorrelationID(correlationID)
+			writeEvents(w, r, events)
+			return
+		}
+
+		limit, offset, err := pageParams(r.URL.Query())
+		if err != nil {
+			a.writeError(w, r, http.StatusBadRequest, err)
+			return
+		}
+
+		status := model.EventStatus(r.URL.Query().Get("status"))
+		if status != "" && !status.Valid() {
+			a.writeError(w, r, http.StatusBadRequest, newAPIError(errInvalidStatus))
+			return
+		}
+		fields, err := a.payloadFilters(r.URL.Query())
+		if err != nil {
+			a.writeError(w, r, http.StatusBadRequest, err)
+			return
+		}
+		if status != "" || len(fields) > 0 {
+			var events []*model.Event
+			if len(fields) > 0 {
+				// Every field was checked to be indexed
+				events, _ = a.store.ListByPayloadFields(fields)
+				if status != "" {
+					events = filterByStatus(events, status)
+				}
+			} else {
+				events = a.store.ListByStatus(status)
 			}
+			// Pages of the filtered list, which has no conditional validators
+			total := len(events)
+			w.Header().Set("X-Total-Count", strconv.Itoa(total))
+			writeEvents(w, r, events[min(offset, total):min(offset+limit, total)])
+			return
 		}
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(response)
+
+		// List a page of events. Read the version first so a change racing
+		// the list can only make the ETag older than the body, never newer.
+		seq, modifiedAt := a.store.Version()
+		if listValidators(w, r, a.store.Epoch(), seq, modifiedAt) {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		events, total := a.store.ListPaged(limit, offset)
+		w.Header().Set("X-Total-Count", strconv.Itoa(total))
+		writeEvents(w, r, events)
 		return
 	}
 
 	if r.Method != http.MethodPost {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		a.writeError(w, r, http.StatusMethodNotAllowed, newAPIError(errMethodNotAllowed))
 		return
 	}
 
-	var req model.EventRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		log.Printf("Invalid request body: %v", err)
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+	if !a.beginSubmission() {
+		a.writeError(w, r, http.StatusServiceUnavailable, newAPIError(errShuttingDown))
 		return
 	}
+	defer a.submissions.Done()
 
-	if req.EventID == "" {
-		http.Error(w, "event_id is required", http.StatusBadRequest)
+	liftDeadline := a.limitBody(w, r)
+	req, err := decodeEventRequest(r)
+	liftDeadline()
+	if a.rejectBody(w, r, err) {
 		return
 	}
 
-	// Check for idempotency
-	if a.store.Exists(req.EventID) {
-		log.Printf("Event already exists: %s", req.EventID)
-		w.WriteHeader(http.StatusConflict)
+	result := a.submit(r, req)
+	if result.status == http.StatusTooManyRequests {
+		w.Header().Set("Retry-After", queueFullRetryAfter)
+	}
+	if result.err != nil {
+		a.writeError(w, r, result.status, result.err)
 		return
 	}
+	if result.mergedInto != "" {
+		w.Header().Set("X-Coalesced-Into", result.mergedInto)
+	}
+	if result.queued {
+		a.adviseSaturation(w)
+	}
+	if result.generated {
+		// The producer has no other way to learn the ID it was given
+		w.Header().Set("Location", a.config.BasePath+"/events/"+url.PathEscape(result.eventID))
+		writeJSON(w, result.status, model.AcceptedResponse{EventID: result.eventID})
+		return
+	}
+	w.WriteHeader(result.status)
+}
+
+// adviseSaturation sets X-Queue-Saturation once the queue is at least
+// QUEUE_SATURATION_THRESHOLD full, so producers can slow down before the
+// queue is full and blocks. A threshold of 0 disables the header.
+func (a *App) adviseSaturation(w http.ResponseWriter) {
+	if a.config.QueueSaturationThreshold <= 0 {
+		return
+	}
+	if saturation := a.worker.QueueSaturation(); saturation >= a.config.QueueSaturationThreshold {
+		w.Header().Set("X-Queue-Saturation", strconv.FormatFloat(saturation, 'f', 2, 64))
+	}
+}
+
+// rejectBody answers a submission whose body could not be read or decoded,
+// returning false if err is nil
+func (a *App) rejectBody(w http.ResponseWriter, r *http.Request, err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, os.ErrDeadlineExceeded) {
+		logf(r, "Request body read timed out")
+		// The rest of the body may never arrive, so don't wait to drain it
+		w.Header().Set("Connection", "close")
+		a.writeError(w, r, http.StatusRequestTimeout, newAPIError(errBodyReadTimeout))
+		return true
+	}
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		logf(r, "Request body exceeds %d bytes", tooLarge.Limit)
+		a.writeError(w, r, http.StatusRequestEntityTooLarge, newAPIError(errPayloadTooLarge, tooLarge.Limit))
+		return true
+	}
+	logf(r, "Invalid request body: %v", err)
+	a.writeError(w, r, http.StatusBadRequest, newAPIError(errInvalidRequestBody))
+	return true
+}
+
+// queueFullRetryAfter is the Retry-After, in seconds, of submissions
+// rejected because the queue is full
+const queueFullRetryAfter = "1"
+
+// submission is the outcome of submitting one event
+type submission struct {
+	eventID string
+	// status is what POST /events answers with; err, when set, is the
+	// error it reports
+	status int
+	err    *apiError
+	// generated is set when the event_id was generated for the event
+	generated bool
+	// queued is set when the event was stored and queued for processing
+	queued bool
+	// mergedInto is the event a coalesced event was merged into
+	mergedInto string
+}
+
+// submit validates, stores and enqueues one event
+func (a *App) submit(r *http.Request, req model.EventRequest) submission {
+	var err error
+	generated := req.EventID == "" && a.config.AllowGeneratedIDs
+	if generated {
+		req.EventID, err = newEventID()
+		if err != nil {
+			logf(r, "Failed to generate event ID: %v", err)
+			return submission{status: http.StatusInternalServerError, err: newAPIError(errEventIDGeneration)}
+		}
+	}
 
-	// Create and save event
+	eventID, err := a.validateEventID(req.EventID)
+	if err != nil {
+		return submission{eventID: req.EventID, status: http.StatusBadRequest, err: asAPIError(err)}
+	}
+	req.EventID = eventID
+
+	if err := a.validatePayload(req.Payload); err != nil {
+		return submission{eventID: req.EventID, status: http.StatusBadRequest, err: newAPIError(errInvalidPayload, err.Error())}
+	}
+	req.Payload = defaultPayload(req.Payload)
+
+	now := time.Now()
+	processAt, err := scheduleTime(req, now)
+	if err != nil {
+		return submission{eventID: req.EventID, status: http.StatusBadRequest, err: asAPIError(err)}
+	}
+	if err := validateCallbackURL(req.CallbackURL); err != nil {
+		return submission{eventID: req.EventID, status: http.StatusBadRequest, err: asAPIError(err)}
+	}
+	if req.Priority < 0 {
+		return submission{eventID: req.EventID, status: http.StatusBadRequest, err: newAPIError(errNegativePriority)}
+	}
+	status := model.StatusAccepted
+	if processAt.After(now) {
+		status = model.StatusScheduled
+	}
+
+	// Create and save event, checking for idempotency atomically
 	event := &model.Event{
-		EventID: req.EventID,
-		Payload: req.Payload,
-		Status:  model.StatusAccepted,
+		EventID:       req.EventID,
+		Type:          req.Type,
+		Payload:       req.Payload,
+		Status:        status,
+		CorrelationID: req.CorrelationID,
+		CausationID:   req.CausationID,
+		ProcessAt:     processAt,
+		Priority:      req.Priority,
+		CreatedAt:     time.Now(),
+		RequestID:     requestID(r),
+		TraceContext:  traceContext(r),
+		CallbackURL:   req.CallbackURL,
+	}
+	event.DedupKey, _ = dedupKey(req.Payload, a.config.DedupKeyPaths)
+	if a.config.DuplicatePolicy == duplicateCompare {
+		event.PayloadHash = payloadHash(req.Payload)
+	}
+	if !a.worker.ReserveBytes(event) {
+		// A known event is still a duplicate, however full the budget is
+		if _, exists := a.store.GetStatus(event.EventID); exists {
+			return a.rejectDuplicate(r, event)
+		}
+		return submission{eventID: event.EventID, status: http.StatusServiceUnavailable, err: newAPIError(errInflightBytesExceeded)}
+	}
+	// A due event needs a place in the queue before it is stored, so a full
+	// queue rejects it instead of blocking the request. Scheduled events only
+	// join the queue once they are due.
+	if status == model.StatusAccepted {
+		if err := a.worker.ReserveSlot(event); err != nil {
+			a.worker.ReleaseBytes(event)
+			if _, exists := a.store.GetStatus(event.EventID); exists {
+				return a.rejectDuplicate(r, event)
+			}
+			if errors.Is(err, worker.ErrStopped) {
+				return submission{eventID: event.EventID, status: http.StatusServiceUnavailable, err: newAPIError(errShuttingDown)}
+			}
+			logf(r, "Queue full, rejecting event %s", event.EventID)
+			return submission{eventID: event.EventID, status: http.StatusTooManyRequests, err: newAPIError(errQueueFull)}
+		}
+	}
+	release := func() {
+		a.worker.ReleaseBytes(event)
+		a.worker.ReleaseSlot(event)
+	}
+	// Events with a dedup key are coalesced when enabled, and only enqueued
+	// by the coalescer once their window ends
+	coalesce := a.coalescer != nil && event.DedupKey != ""
+	var saved bool
+	if coalesce {
+		var mergedInto string
+		mergedInto, saved, err = a.coalescer.submit(event, func() (bool, error) {
+			return a.store.SaveIfAbsent(event)
+		})
+		if mergedInto != "" {
+			release()
+			logf(r, "Event %s coalesced into %s", req.EventID, mergedInto)
+			return submission{eventID: event.EventID, status: http.StatusAccepted, mergedInto: mergedInto}
+		}
+	} else {
+		saved, err = a.store.SaveIfAbsent(event)
+	}
+	if errors.Is(err, store.ErrPersistence) {
+		release()
+		return submission{eventID: event.EventID, status: http.StatusServiceUnavailable, err: newAPIError(errStoreUnavailable)}
+	}
+	if err != nil {
+		release()
+		logf(r, "Idempotency check failed for %s: %v", req.EventID, err)
+		return submission{eventID: event.EventID, status: http.StatusServiceUnavailable, err: newAPIError(errIdempotencyUnavailable)}
+	}
+	if !saved {
+		release()
+		return a.rejectDuplicate(r, event)
 	}
-	a.store.Save(event)
 
-	// Enqueue for background processing
-	a.worker.Enqueue(event)
+	// Enqueue for background processing, into the place reserved above
+	if !coalesce {
+		if err := a.worker.Enqueue(event); err != nil {
+			// The worker stopped since the reservation; take the event back
+			// so a retry against the next instance isn't a duplicate
+			logf(r, "Failed to enqueue event %s: %v", event.EventID, err)
+			release()
+			a.store.Delete(event.EventID)
+			return submission{eventID: event.EventID, status: http.StatusServiceUnavailable, err: newAPIError(errShuttingDown)}
+		}
+	}
 
-	log.Printf("Event accepted: %s", req.EventID)
-	w.WriteHeader(http.StatusAccepted)
+	a.accepted.Add(1)
+	logf(r, "Event accepted: %s", req.EventID)
+	return submission{eventID: event.EventID, status: http.StatusAccepted, generated: generated, queued: true}
+}
+
+// rejectDuplicate answers a submission whose event_id or dedup key is
+// already taken. With the compare duplicate policy, resubmitting an event_id
+// with the same payload is a safe retry and is acknowledged with 202, while a
+// different payload means the ID was reused and gets an explicit 409 error.
+func (a *App) rejectDuplicate(r *http.Request, event *model.Event) submission {
+	a.duplicates.Add(1)
+	if a.config.DuplicatePolicy == duplicateCompare {
+		if stored, exists := a.store.Get(event.EventID); exists {
+			if stored.PayloadHash == event.PayloadHash {
+				logf(r, "Event %s resubmitted with the same payload", event.EventID)
+				return submission{eventID: event.EventID, status: http.StatusAccepted}
+			}
+			logf(r, "Event %s resubmitted with a different payload", event.EventID)
+			return submission{eventID: event.EventID, status: http.StatusConflict, err: newAPIError(errEventIDReused)}
+		}
+	}
+	logf(r, "Event already exists: %s", event.EventID)
+	return submission{eventID: event.EventID, status: http.StatusConflict}
+}
+
+// handleValidate handles POST /events/validate, reporting every validation
+// problem with an event without creating it
+func (a *App) handleValidate(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		a.writeError(w, r, http.StatusMethodNotAllowed, newAPIError(errMethodNotAllowed))
+		return
+	}
+
+	liftDeadline := a.limitBody(w, r)
+	req, err := decodeEventRequest(r)
+	liftDeadline()
+	// A body that is too large or too slow is rejected as for POST /events;
+	// one that doesn't decode is a validation result
+	var tooLarge *http.MaxBytesError
+	if errors.Is(err, os.ErrDeadlineExceeded) || errors.As(err, &tooLarge) {
+		a.rejectBody(w, r, err)
+		return
+	}
+	if err != nil {
+		writeJSON(w, http.StatusOK, model.ValidationResponse{
+			Valid:  false,
+			Errors: []string{"invalid request body: " + err.Error()},
+		})
+		return
+	}
+
+	errs := a.validateEventRequest(req)
+	writeJSON(w, http.StatusOK, model.ValidationResponse{
+		Valid:  len(errs) == 0,
+		Errors: errs,
+	})
+}
+
+// handleConfig handles GET /admin/config, returning the effective
+// configuration after env parsing and defaults, with secrets redacted
+func (a *App) handleConfig(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		a.writeError(w, r, http.StatusMethodNotAllowed, newAPIError(errMethodNotAllowed))
+		return
+	}
+	writeJSON(w, http.StatusOK, redactedConfig(a.config))
+}
+
+// handleEventCounts handles GET /events/count, a cheap summary of the store
+// that doesn't encode any event
+func (a *App) handleEventCounts(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		a.writeError(w, r, http.StatusMethodNotAllowed, newAPIError(errMethodNotAllowed))
+		return
+	}
+	counts := a.store.Counts()
+	resp := model.EventCountsResponse{
+		Accepted:  counts[model.StatusAccepted],
+		Scheduled: counts[model.StatusScheduled],
+		Processed: counts[model.StatusProcessed],
+		Failed:    counts[model.StatusFailed],
+	}
+	for _, n := range counts {
+		resp.Total += n
+	}
+	writeResponse(w, r, http.StatusOK, resp)
+}
+
+// handleEventRoutes dispatches requests for a single event under /events/{id}
+func (a *App) handleEventRoutes(w http.ResponseWriter, r *http.Request) {
+	eventID, action, ok := parseEventPath(r.URL.EscapedPath())
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	switch action {
+	case "":
+		switch r.Method {
+		case http.MethodGet:
+			a.handleGetEvent(w, r, eventID)
+		case http.MethodPatch:
+			a.handlePatchEvent(w, r, eventID)
+		default:
+			a.handleDeleteEvent(w, r, eventID)
+		}
+	case "history":
+		a.handleEventHistory(w, r, eventID)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// handleGetEvent handles GET /events/{id}, returning the event along with
+// its status transitions
+func (a *App) handleGetEvent(w http.ResponseWriter, r *http.Request, eventID string) {
+	event, exists := a.store.Get(eventID)
+	if !exists {
+		a.writeError(w, r, http.StatusNotFound, newAPIError(errEventNotFound))
+		return
+	}
+	resp := model.EventDetailResponse{
+		EventResponse: toEventResponses([]*model.Event{event})[0],
+		Transitions:   emptyIfNil(event.Transitions),
+	}
+	writeResponse(w, r, http.StatusOK, resp)
+}
+
+// handleDeleteEvent handles DELETE /events/{id}. A queued event that is
+// deleted is skipped by the worker, but processing already under way runs
+// to completion.
+func (a *App) handleDeleteEvent(w http.ResponseWriter, r *http.Request, eventID string) {
+	if r.Method != http.MethodDelete {
+		a.writeError(w, r, http.StatusMethodNotAllowed, newAPIError(errMethodNotAllowed))
+		return
+	}
+
+	deleted, err := a.store.Delete(eventID)
+	if err != nil {
+		logf(r, "Failed to delete event %s: %v", eventID, err)
+		a.writeError(w, r, http.StatusServiceUnavailable, newAPIError(errStoreUnavailable))
+		return
+	}
+	if !deleted {
+		a.writeError(w, r, http.StatusNotFound, newAPIError(errEventNotFound))
+		return
+	}
+	logf(r, "Deleted event %s", eventID)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handlePatchEvent handles PATCH /events/{id}, replacing the payload of an
+// event that has not been processed yet with the JSON request body. An
+// event already being processed may still see its old payload.
+func (a *App) handlePatchEvent(w http.ResponseWriter, r *http.Request, eventID string) {
+	liftDeadline := a.limitBody(w, r)
+	payload, err := io.ReadAll(r.Body)
+	liftDeadline()
+	if a.rejectBody(w, r, err) {
+		return
+	}
+	if err := a.validatePayload(payload); err != nil {
+		a.writeError(w, r, http.StatusBadRequest, newAPIError(errInvalidPayload, err.Error()))
+		return
+	}
+
+	updated, err := a.store.UpdatePayload(eventID, defaultPayload(payload))
+	if errors.Is(err, store.ErrNotFound) {
+		a.writeError(w, r, http.StatusNotFound, newAPIError(errEventNotFound))
+		return
+	}
+	if err != nil {
+		logf(r, "Failed to persist payload of event %s: %v", eventID, err)
+		a.writeError(w, r, http.StatusServiceUnavailable, newAPIError(errStoreUnavailable))
+		return
+	}
+	event, exists := a.store.Get(eventID)
+	if !exists {
+		// Deleted since the update
+		a.writeError(w, r, http.StatusNotFound, newAPIError(errEventNotFound))
+		return
+	}
+	if !updated {
+		a.writeError(w, r, http.StatusConflict, newAPIError(errEventFinished, event.Status))
+		return
+	}
+	logf(r, "Updated payload of event %s", eventID)
+	writeJSON(w, http.StatusOK, toEventResponses([]*model.Event{event})[0])
+}
+
+// handleEventHistory handles GET /events/{id}/history
+func (a *App) handleEventHistory(w http.ResponseWriter, r *http.Request, eventID string) {
+	if r.Method != http.MethodGet {
+		a.writeError(w, r, http.StatusMethodNotAllowed, newAPIError(errMethodNotAllowed))
+		return
+	}
+
+	history, exists := a.store.History(eventID)
+	if !exists {
+		a.writeError(w, r, http.StatusNotFound, newAPIError(errEventNotFound))
+		return
+	}
+
+	resp := model.EventHistoryResponse{
+		EventID: eventID,
+		History: emptyIfNil(history),
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// parseEventPath splits an escaped /events/{id}[/{action}] path into the
+// unescaped event ID and the optional action
+func parseEventPath(escapedPath string) (eventID, action string, ok bool) {
+	parts := strings.Split(strings.TrimPrefix(escapedPath, "/events/"), "/")
+	if len(parts) > 2 || parts[0] == "" {
+		return "", "", false
+	}
+	eventID, err := url.PathUnescape(parts[0])
+	if err != nil {
+		return "", "", false
+	}
+	if len(parts) == 2 {
+		action = parts[1]
+	}
+	return eventID, action, true
+}
+
+// beginSubmission registers an in-flight event submission. It returns false
+// once the application is draining so the request can be rejected up front.
+func (a *App) beginSubmission() bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.draining {
+		return false
+	}
+	a.submissions.Add(1)
+	return true
+}
+
+// isDraining reports whether shutdown has begun
+func (a *App) isDraining() bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.draining
+}
+
+// handleEventsSync handles GET /events?modified_after=CURSOR, returning only
+// events changed since the given cursor plus the cursor for the next poll
+func (a *App) handleEventsSync(w http.ResponseWriter, r *http.Request, modifiedAfter string) {
+	seq, err := a.parseCursor(modifiedAfter)
+	if err != nil {
+		status := http.StatusBadRequest
+		if err.code == errCursorExpired {
+			status = http.StatusGone
+		}
+		a.writeError(w, r, status, err)
+		return
+	}
+
+	events, cursor := a.store.ListModifiedAfter(seq)
+	resp := model.EventSyncResponse{
+		Events: toEventResponses(events),
+		Cursor: a.store.Epoch() + "-" + strconv.FormatUint(cursor, 10),
+	}
+	writeResponse(w, r, http.StatusOK, resp)
+}
+
+// parseCursor returns the update sequence of a sync cursor of the form
+// EPOCH-SEQ, or 0 for a first sync. Sequences restart on every instance,
+// so a cursor from another epoch can't be resumed: it may point past
+// changes this instance has made, which would then never be returned.
+func (a *App) parseCursor(cursor string) (uint64, *apiError) {
+	epoch, seqText, found := strings.Cut(cursor, "-")
+	if !found {
+		// A bare sequence other than 0 predates epochs and can't be checked
+		seq, err := strconv.ParseUint(cursor, 10, 64)
+		if err != nil {
+			return 0, newAPIError(errInvalidModifiedAfter)
+		}
+		if seq != 0 {
+			return 0, newAPIError(errCursorExpired)
+		}
+		return 0, nil
+	}
+	seq, err := strconv.ParseUint(seqText, 10, 64)
+	if err != nil || epoch == "" {
+		return 0, newAPIError(errInvalidModifiedAfter)
+	}
+	if epoch != a.store.Epoch() {
+		return 0, newAPIError(errCursorExpired)
+	}
+	return seq, nil
+}
+
+// Page size of GET /events when no limit is given, and the largest allowed
+const (
+	defaultPageLimit = 100
+	maxPageLimit     = 1000
+)
+
+// pageParams parses ?limit= and ?offset=. A limit above maxPageLimit is
+// capped rather than rejected.
+func pageParams(query url.Values) (limit, offset int, err *apiError) {
+	limit = defaultPageLimit
+	if limitStr := query.Get("limit"); limitStr != "" {
+		value, convErr := strconv.Atoi(limitStr)
+		if convErr != nil || value < 1 {
+			return 0, 0, newAPIError(errInvalidLimit)
+		}
+		limit = min(value, maxPageLimit)
+	}
+	if offsetStr := query.Get("offset"); offsetStr != "" {
+		value, convErr := strconv.Atoi(offsetStr)
+		if convErr != nil || value < 0 {
+			return 0, 0, newAPIError(errInvalidOffset)
+		}
+		offset = value
+	}
+	return limit, offset, nil
+}
+
+// payloadFilters collects the ?payload.<field>=value filters, which must all
+// be on fields declared in INDEXED_FIELDS
+func (a *App) payloadFilters(query url.Values) (map[string]string, *apiError) {
+	var filters map[string]string
+	for param, values := range query {
+		field, ok := strings.CutPrefix(param, "payload.")
+		if !ok {
+			continue
+		}
+		if !a.store.IsIndexed(field) {
+			return nil, newAPIError(errFieldNotIndexed, param)
+		}
+		if filters == nil {
+			filters = make(map[string]string)
+		}
+		filters[field] = values[0]
+	}
+	return filters, nil
+}
+
+// filterByStatus keeps the events with the given status
+func filterByStatus(events []*model.Event, status model.EventStatus) []*model.Event {
+	filtered := make([]*model.Event, 0, len(events))
+	for _, event := range events {
+		if event.Status == status {
+			filtered = append(filtered, event)
+		}
+	}
+	return filtered
+}
+
+// toEventResponses converts stored events to their API representation.
+// The result is never nil, so an empty list encodes as [].
+func toEventResponses(events []*model.Event) []model.EventResponse {
+	response := make([]model.EventResponse, len(events))
+	for i, event := range events {
+		response[i] = model.EventResponse{
+			EventID:       event.EventID,
+			Type:          event.Type,
+			Payload:       event.Payload,
+			Status:        event.Status,
+			CorrelationID: event.CorrelationID,
+			CausationID:   event.CausationID,
+			ProcessAt:     optionalTime(event.ProcessAt),
+			Priority:      event.Priority,
+			Attempts:      event.Attempts,
+			Checkpoint:    event.Checkpoint,
+			CreatedAt:     event.CreatedAt,
+			ProcessedAt:   event.ProcessedAt,
+			UpdatedSeq:    event.UpdatedSeq,
+		}
+	}
+	return response
 }
 
 // handleHealth handles GET /health
 func (a *App) handleHealth(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		a.writeError(w, r, http.StatusMethodNotAllowed, newAPIError(errMethodNotAllowed))
 		return
 	}
 
 	uptime := time.Since(a.startTime).String()
 	resp := model.HealthResponse{
-		Status: "ok",
-		Uptime: uptime,
+		Status:        "ok",
+		Uptime:        uptime,
+		InflightBytes: a.worker.InflightBytes(),
+		QueueDepth:    a.worker.QueueDepth(),
+		QueueCapacity: a.worker.QueueCapacity(),
+	}
+	// Still 200: /health is for diagnostics and alerting, /ready for routing
+	if threshold := a.config.HealthDegradedThreshold; threshold > 0 && a.worker.QueueSaturation() >= threshold {
+		resp.Status = "degraded"
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(resp)
+	writeJSON(w, http.StatusOK, resp)
 }
 
 // handleReady handles GET /ready
 func (a *App) handleReady(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		a.writeError(w, r, http.StatusMethodNotAllowed, newAPIError(errMethodNotAllowed))
 		return
 	}
 
-	if !a.worker.IsRunning() {
+	if !a.worker.IsRunning() || a.isDraining() {
 		resp := model.ReadyResponse{
 			Status: "not ready",
 			Ready:  false,
 		}
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusServiceUnavailable)
-		json.NewEncoder(w).Encode(resp)
+		writeJSON(w, http.StatusServiceUnavailable, resp)
+		return
+	}
+
+	// New submissions would only be rejected with 429 until the backlog clears
+	if a.worker.QueueSaturated() {
+		resp := model.ReadyResponse{
+			Status: "saturated",
+			Ready:  false,
+		}
+		writeJSON(w, http.StatusServiceUnavailable, resp)
+		return
+	}
+
+	// Dependencies can be up while processing mostly fails; shed traffic then too
+	if a.errorRateDegraded() {
+		resp := model.ReadyResponse{
+			Status: "degraded",
+			Ready:  false,
+		}
+		writeJSON(w, http.StatusServiceUnavailable, resp)
 		return
 	}
 
@@ -178,33 +1502,252 @@ func (a *App) handleReady(w http.ResponseWriter, r *http.Request) {
 		Status: "ready",
 		Ready:  true,
 	}
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(resp)
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// errorRateDegraded reports whether more than READY_MAX_ERROR_RATE of the
+// recent processing attempts failed. Too few attempts to judge, fewer than
+// ERROR_RATE_MIN_ATTEMPTS, never count as degraded.
+func (a *App) errorRateDegraded() bool {
+	if a.config.ReadyMaxErrorRate <= 0 {
+		return false
+	}
+	rate, attempts := a.worker.RecentErrorRate()
+	return attempts >= a.config.ErrorRateMinAttempts && rate > a.config.ReadyMaxErrorRate
+}
+
+// handleTick handles POST /admin/tick?n=N, processing up to N queued events
+// when the worker runs in manual mode
+func (a *App) handleTick(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		a.writeError(w, r, http.StatusMethodNotAllowed, newAPIError(errMethodNotAllowed))
+		return
+	}
+
+	if a.worker.Mode() != worker.ModeManual {
+		a.writeError(w, r, http.StatusConflict, newAPIError(errNotManualMode))
+		return
+	}
+
+	n := 1
+	if nStr := r.URL.Query().Get("n"); nStr != "" {
+		value, err := strconv.Atoi(nStr)
+		if err != nil || value < 1 {
+			a.writeError(w, r, http.StatusBadRequest, newAPIError(errInvalidTickCount))
+			return
+		}
+		n = value
+	}
+
+	resp := model.TickResponse{
+		Processed: a.worker.Tick(n),
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// handlePauseType handles POST /admin/pause?type=T, holding back events of
+// that type while other types keep processing
+func (a *App) handlePauseType(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		a.writeError(w, r, http.StatusMethodNotAllowed, newAPIError(errMethodNotAllowed))
+		return
+	}
+
+	eventType := r.URL.Query().Get("type")
+	if eventType == "" {
+		a.writeError(w, r, http.StatusBadRequest, newAPIError(errTypeRequired))
+		return
+	}
+
+	a.worker.PauseType(eventType)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleResumeType handles POST /admin/resume?type=T, releasing held events
+func (a *App) handleResumeType(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		a.writeError(w, r, http.StatusMethodNotAllowed, newAPIError(errMethodNotAllowed))
+		return
+	}
+
+	eventType := r.URL.Query().Get("type")
+	if eventType == "" {
+		a.writeError(w, r, http.StatusBadRequest, newAPIError(errTypeRequired))
+		return
+	}
+
+	a.worker.ResumeType(eventType)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handlePausedTypes handles GET /debug/paused
+func (a *App) handlePausedTypes(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		a.writeError(w, r, http.StatusMethodNotAllowed, newAPIError(errMethodNotAllowed))
+		return
+	}
+
+	paused := a.worker.PausedTypes()
+	resp := model.PausedTypesResponse{
+		PausedTypes: make([]model.PausedType, 0, len(paused)),
+	}
+	for eventType, held := range paused {
+		resp.PausedTypes = append(resp.PausedTypes, model.PausedType{Type: eventType, Held: held})
+	}
+	sort.Slice(resp.PausedTypes, func(i, j int) bool {
+		return resp.PausedTypes[i].Type < resp.PausedTypes[j].Type
+	})
+	writeJSON(w, http.StatusOK, resp)
 }
 
 // Helper functions for environment variable parsing
 
-func getEnv(key, defaultValue string) string {
-	value := os.Getenv(key)
+// envSource reads settings from environment variables, falling back to
+// CONFIG_FILE, and remembers the values it could not parse so
+// Config.Validate can report them
+type envSource struct {
+	file    *configFile
+	invalid []string
+}
+
+// lookup returns the environment variable, or the config file's value for
+// it when it is unset or empty
+func (e *envSource) lookup(key string) string {
+	var fileValue string
+	if e.file != nil {
+		fileValue = e.file.lookup(key)
+	}
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fileValue
+}
+
+// problems returns the parse errors along with the config file keys that
+// match no setting. Call it once every setting was read.
+func (e *envSource) problems() []string {
+	problems := e.invalid
+	if e.file != nil {
+		for _, key := range e.file.unknownKeys() {
+			problems = append(problems, fmt.Sprintf("CONFIG_FILE key %s is not a known setting", strings.ToLower(key)))
+		}
+	}
+	return problems
+}
+
+func (e *envSource) getEnv(key, defaultValue string) string {
+	value := e.lookup(key)
 	if value == "" {
 		return defaultValue
 	}
 	return value
 }
 
-func getEnvAsInt(key string, defaultValue int) int {
-	valueStr := os.Getenv(key)
+func (e *envSource) getEnvAsInt(key string, defaultValue int) int {
+	valueStr := e.lookup(key)
 	if valueStr == "" {
 		return defaultValue
 	}
 	value, err := strconv.Atoi(valueStr)
 	if err != nil {
 		log.Printf("Invalid value for %s: %s, using default: %d", key, valueStr, defaultValue)
+		e.invalid = append(e.invalid, fmt.Sprintf("%s=%q is not an integer", key, valueStr))
 		return defaultValue
 	}
 	return value
 }
 
+// optionalTime returns nil for the zero time so it is omitted from JSON
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+// normalizeBasePath turns "event-service/" or "/event-service/" into
+// "/event-service", and "/" into "" (served at the root)
+func normalizeBasePath(basePath string) string {
+	basePath = strings.Trim(basePath, "/")
+	if basePath == "" {
+		return ""
+	}
+	return "/" + basePath
+}
+
+func (e *envSource) getEnvAsBool(key string, defaultValue bool) bool {
+	valueStr := e.lookup(key)
+	if valueStr == "" {
+		return defaultValue
+	}
+	value, err := strconv.ParseBool(valueStr)
+	if err != nil {
+		log.Printf("Invalid value for %s: %s, using default: %t", key, valueStr, defaultValue)
+		e.invalid = append(e.invalid, fmt.Sprintf("%s=%q is not a boolean", key, valueStr))
+		return defaultValue
+	}
+	return value
+}
+
+func (e *envSource) getEnvAsFloat(key string, defaultValue float64) float64 {
+	valueStr := e.lookup(key)
+	if valueStr == "" {
+		return defaultValue
+	}
+	value, err := strconv.ParseFloat(valueStr, 64)
+	if err != nil {
+		log.Printf("Invalid value for %s: %s, using default: %g", key, valueStr, defaultValue)
+		e.invalid = append(e.invalid, fmt.Sprintf("%s=%q is not a number", key, valueStr))
+		return defaultValue
+	}
+	return value
+}
+
+// getEnvAsIntMap parses "key=value,key=value" into a map of ints.
+// Invalid entries are logged and skipped.
+func (e *envSource) getEnvAsIntMap(key string, defaultValue map[string]int) map[string]int {
+	valueStr := e.lookup(key)
+	if valueStr == "" {
+		return defaultValue
+	}
+	values := make(map[string]int)
+	for _, entry := range strings.Split(valueStr, ",") {
+		name, numStr, found := strings.Cut(strings.TrimSpace(entry), "=")
+		num, err := strconv.Atoi(strings.TrimSpace(numStr))
+		if !found || strings.TrimSpace(name) == "" || err != nil {
+			log.Printf("Invalid entry in %s: %q, skipping", key, entry)
+			e.invalid = append(e.invalid, fmt.Sprintf("%s entry %q is not name=integer", key, entry))
+			continue
+		}
+		values[strings.TrimSpace(name)] = num
+	}
+	return values
+}
+
+// getEnvAsList parses "a,b,c" into a list, dropping empty entries
+func (e *envSource) getEnvAsList(key string, defaultValue []string) []string {
+	valueStr := e.lookup(key)
+	if valueStr == "" {
+		return defaultValue
+	}
+	var values []string
+	for _, entry := range strings.Split(valueStr, ",") {
+		if entry = strings.TrimSpace(entry); entry != "" {
+			values = append(values, entry)
+		}
+	}
+	return values
+}
+
+// msDurations converts a map of millisecond values to durations
+func msDurations(values map[string]int) map[string]time.Duration {
+	durations := make(map[string]time.Duration, len(values))
+	for key, ms := range values {
+		durations[key] = time.Duration(ms) * time.Millisecond
+	}
+	return durations
+}
+
 // GetServer returns the underlying HTTP server (useful for testing or custom shutdown)
 func (a *App) GetServer() *http.Server {
 	return a.server
@@ -228,7 +1771,14 @@ func (a *App) handleFrontend(w http.ResponseWriter, r *http.Request) {
 	}
 
 	w.Header().Set("Content-Type", "text/html; charset=utf-8")
-	w.Write([]byte(frontendHTML))
+	w.Write([]byte(a.frontend))
+}
+
+// renderFrontend templates the base path into the dashboard's fetch URLs
+func renderFrontend(basePath string) string {
+	// json.Marshal yields a safely escaped JS string literal
+	literal, _ := json.Marshal(basePath)
+	return strings.Replace(frontendHTML, "__BASE_PATH__", string(literal), 1)
 }
 
 const frontendHTML = `<!DOCTYPE html>
@@ -428,11 +1978,26 @@ const frontendHTML = `<!DOCTYPE html>
             color: #d97706;
         }
 
+        .status-scheduled {
+            background: #e0e7ff;
+            color: #4f46e5;
+        }
         .status-processed {
             background: #d1fae5;
             color: #059669;
         }
 
+        .status-failed {
+            background: #fee2e2;
+            color: #dc2626;
+        }
+
+        .event-attempts {
+            font-size: 12px;
+            color: #718096;
+            margin-bottom: 10px;
+        }
+
         .event-payload {
             background: #f7fafc;
             padding: 10px;
@@ -532,7 +2097,16 @@ const frontendHTML = `<!DOCTYPE html>
             <div class="card">
                 <div style="display: flex; justify-content: space-between; align-items: center; margin-bottom: 20px;">
                     <h2 style="margin: 0;">Events</h2>
-                    <button class="refresh-btn" onclick="loadEvents()" style="width: auto; padding: 8px 16px; margin: 0;">Refresh</button>
+                    <div style="display: flex; gap: 10px; align-items: center;">
+                        <select id="status-filter" onchange="loadEvents()" style="padding: 8px; border-radius: 5px; border: 1px solid #e2e8f0;">
+                            <option value="">All statuses</option>
+                            <option value="accepted">Accepted</option>
+                            <option value="scheduled">Scheduled</option>
+                            <option value="processed">Processed</option>
+                            <option value="failed">Failed</option>
+                        </select>
+                        <button class="refresh-btn" onclick="loadEvents()" style="width: auto; padding: 8px 16px; margin: 0;">Refresh</button>
+                    </div>
                 </div>
                 <div id="events-list" class="event-list">
                     <div class="empty-state">Loading events...</div>
@@ -542,12 +2116,15 @@ const frontendHTML = `<!DOCTYPE html>
     </div>
 
     <script>
+        // Prefix for all API calls when served under BASE_PATH
+        const BASE_PATH = __BASE_PATH__;
+
         let autoRefresh = null;
 
         // Load service health
         async function loadHealth() {
             try {
-                const response = await fetch('/health');
+                const response = await fetch(BASE_PATH + '/health');
                 const data = await response.json();
                 document.getElementById('service-status').textContent = data.status;
                 document.getElementById('uptime').textContent = data.uptime;
@@ -560,7 +2137,7 @@ const frontendHTML = `<!DOCTYPE html>
         // Load worker readiness
         async function loadReady() {
             try {
-                const response = await fetch('/ready');
+                const response = await fetch(BASE_PATH + '/ready');
                 const data = await response.json();
                 const statusEl = document.getElementById('worker-status');
                 statusEl.textContent = data.ready ? 'ready' : 'not ready';
@@ -572,14 +2149,26 @@ const frontendHTML = `<!DOCTYPE html>
             }
         }
 
+        // Load event counts, without fetching the events themselves
+        async function loadCounts() {
+            try {
+                const response = await fetch(BASE_PATH + '/events/count');
+                const counts = await response.json();
+                const totalEl = document.getElementById('total-events');
+                totalEl.textContent = counts.total;
+                totalEl.title = counts.accepted + ' accepted, ' + counts.scheduled + ' scheduled, ' +
+                    counts.processed + ' processed, ' + counts.failed + ' failed';
+            } catch (error) {
+                document.getElementById('total-events').textContent = '-';
+            }
+        }
+
         // Load events
         async function loadEvents() {
             try {
-                const response = await fetch('/events');
+                const status = document.getElementById('status-filter').value;
+                const response = await fetch(BASE_PATH + '/events' + (status ? '?status=' + status : ''));
                 const events = await response.json();
-
-                document.getElementById('total-events').textContent = events.length;
-
                 const eventsListEl = document.getElementById('events-list');
 
                 if (events.length === 0) {
@@ -593,6 +2182,8 @@ const frontendHTML = `<!DOCTYPE html>
                             '<span class="event-id">' + escapeHtml(event.event_id) + '</span>' +
                             '<span class="event-status status-' + event.status + '">' + event.status + '</span>' +
                         '</div>' +
+                        (event.attempts > 1 ? '<div class="event-attempts">' + event.attempts + ' attempts</div>' : '') +
+                        (event.processed_at ? '<div class="event-attempts">processed in ' + formatDuration(new Date(event.processed_at) - new Date(event.created_at)) + '</div>' : '') +
                         '<div class="event-payload">' + formatJSON(event.payload) + '</div>' +
                     '</div>'
                 ).join('');
@@ -619,7 +2210,7 @@ const frontendHTML = `<!DOCTYPE html>
             }
 
             try {
-                const response = await fetch('/events', {
+                const response = await fetch(BASE_PATH + '/events', {
                     method: 'POST',
                     headers: {
                         'Content-Type': 'application/json',
@@ -634,7 +2225,7 @@ const frontendHTML = `<!DOCTYPE html>
                     showMessage('success', 'Event accepted and queued for processing!');
                     document.getElementById('event-id').value = '';
                     document.getElementById('payload').value = '{}';
-                    setTimeout(loadEvents, 100);
+                    setTimeout(() => { loadCounts(); loadEvents(); }, 100);
                 } else if (response.status === 409) {
                     showMessage('warning', 'Event already exists (duplicate event_id)');
                 } else {
@@ -666,6 +2257,10 @@ const frontendHTML = `<!DOCTYPE html>
             return escapeHtml(JSON.stringify(obj, null, 2));
         }
 
+        function formatDuration(ms) {
+            return ms < 1000 ? ms + ' ms' : (ms / 1000).toFixed(1) + ' s';
+        }
+
         function escapeHtml(text) {
             const div = document.createElement('div');
             div.textContent = text;
@@ -675,12 +2270,14 @@ const frontendHTML = `<!DOCTYPE html>
         // Initial load
         loadHealth();
         loadReady();
+        loadCounts();
         loadEvents();
 
         // Auto-refresh every 2 seconds
         setInterval(() => {
             loadHealth();
             loadReady();
+            loadCounts();
             loadEvents();
         }, 2000);
     </script>
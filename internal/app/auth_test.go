@@ -0,0 +1,99 @@
+package app
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestAPIKey(t *testing.T) {
+	application := newTestApp(t, Config{WorkerMode: "manual", APIKey: "s3cret"})
+	application.worker.Start()
+	defer application.worker.Stop(context.Background())
+	handler := application.routes()
+
+	request := func(method, path, body string, headers map[string]string) int {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		for name, value := range headers {
+			req.Header.Set(name, value)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	tests := []struct {
+		name     string
+		method   string
+		path     string
+		body     string
+		headers  map[string]string
+		expected int
+	}{
+		{"absent key", http.MethodPost, "/events", `{"event_id": "evt_1"}`, nil, http.StatusUnauthorized},
+		{"wrong key", http.MethodPost, "/events", `{"event_id": "evt_1"}`, map[string]string{"Authorization": "Bearer nope"}, http.StatusUnauthorized},
+		{"key prefix", http.MethodPost, "/events", `{"event_id": "evt_1"}`, map[string]string{"X-API-Key": "s3cre"}, http.StatusUnauthorized},
+		{"key with suffix", http.MethodPost, "/events", `{"event_id": "evt_1"}`, map[string]string{"X-API-Key": "s3cret2"}, http.StatusUnauthorized},
+		{"bearer key", http.MethodPost, "/events", `{"event_id": "evt_1"}`, map[string]string{"Authorization": "Bearer s3cret"}, http.StatusAccepted},
+		{"X-API-Key", http.MethodPost, "/events", `{"event_id": "evt_2"}`, map[string]string{"X-API-Key": "s3cret"}, http.StatusAccepted},
+		{"batch without key", http.MethodPost, "/events/batch", `[]`, nil, http.StatusUnauthorized},
+		{"patch without key", http.MethodPatch, "/events/evt_1", `{}`, nil, http.StatusUnauthorized},
+		{"delete without key", http.MethodDelete, "/events/evt_1", "", nil, http.StatusUnauthorized},
+		{"delete with key", http.MethodDelete, "/events/evt_1", "", map[string]string{"X-API-Key": "s3cret"}, http.StatusNoContent},
+		{"list stays open", http.MethodGet, "/events", "", nil, http.StatusOK},
+		{"health stays open", http.MethodGet, "/health", "", nil, http.StatusOK},
+		{"ready stays open", http.MethodGet, "/ready", "", nil, http.StatusOK},
+	}
+	for _, tt := range tests {
+		if code := request(tt.method, tt.path, tt.body, tt.headers); code != tt.expected {
+			t.Errorf("%s: expected %d, got %d", tt.name, tt.expected, code)
+		}
+	}
+}
+
+func TestNoAPIKeyLeavesWritesOpen(t *testing.T) {
+	application := newTestApp(t, Config{WorkerMode: "manual"})
+	application.worker.Start()
+	defer application.worker.Stop(context.Background())
+
+	rec := httptest.NewRecorder()
+	application.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/events", strings.NewReader(`{"event_id": "evt_1"}`)))
+	if rec.Code != http.StatusAccepted {
+		t.Errorf("Expected 202 without API_KEY, got %d", rec.Code)
+	}
+}
+
+func TestAPIKeyProtectsAdminRoutes(t *testing.T) {
+	for _, adminPort := range []string{"", "9091"} {
+		application := newTestApp(t, Config{WorkerMode: "manual", APIKey: "s3cret", MetricsEnabled: true, AdminPort: adminPort})
+		handler := application.routes()
+		if adminPort != "" {
+			handler = application.adminRoutes()
+		}
+
+		for _, route := range []struct{ method, path string }{
+			{http.MethodPost, "/admin/tick"},
+			{http.MethodPost, "/admin/pause?type=order"},
+			{http.MethodPost, "/admin/resume?type=order"},
+			{http.MethodGet, "/admin/config"},
+			{http.MethodGet, "/debug/paused"},
+			{http.MethodGet, "/metrics"},
+		} {
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(route.method, route.path, nil))
+			if rec.Code != http.StatusUnauthorized {
+				t.Errorf("%s %s (admin port %q): expected 401 without a key, got %d", route.method, route.path, adminPort, rec.Code)
+			}
+
+			req := httptest.NewRequest(route.method, route.path, nil)
+			req.Header.Set("X-API-Key", "s3cret")
+			rec = httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			if rec.Code == http.StatusUnauthorized {
+				t.Errorf("%s %s (admin port %q): expected the key to be accepted", route.method, route.path, adminPort)
+			}
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package app
+
+import (
+	"encoding/json"
+	"event-service/internal/model"
+	"net/http"
+)
+
+// handleBatch handles POST /events/batch, submitting each event of a JSON
+// (or MessagePack) array as POST /events would. Events succeed or fail on
+// their own, so the response is 200 with one result per event unless the
+// batch as a whole can't be read.
+func (a *App) handleBatch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		a.writeError(w, r, http.StatusMethodNotAllowed, newAPIError(errMethodNotAllowed))
+		return
+	}
+
+	if !a.beginSubmission() {
+		a.writeError(w, r, http.StatusServiceUnavailable, newAPIError(errShuttingDown))
+		return
+	}
+	defer a.submissions.Done()
+
+	liftDeadline := a.limitBody(w, r)
+	// Items are decoded one by one so a malformed event only fails itself
+	var items []json.RawMessage
+	err := requestCodec(r).decode(r.Body, &items)
+	liftDeadline()
+	if a.rejectBody(w, r, err) {
+		return
+	}
+	if a.config.MaxBatchSize > 0 && len(items) > a.config.MaxBatchSize {
+		a.writeError(w, r, http.StatusBadRequest, newAPIError(errBatchTooLarge, a.config.MaxBatchSize))
+		return
+	}
+
+	results := make([]model.BatchResult, len(items))
+	queued := false
+	for i, item := range items {
+		var req model.EventRequest
+		if err := json.Unmarshal(item, &req); err != nil {
+			logf(r, "Invalid event %d in batch: %v", i, err)
+			results[i] = a.batchResult(w, r, submission{status: http.StatusBadRequest, err: newAPIError(errInvalidRequestBody)})
+			continue
+		}
+		result := a.submit(r, req)
+		queued = queued || result.queued
+		results[i] = a.batchResult(w, r, result)
+	}
+
+	if queued {
+		a.adviseSaturation(w)
+	}
+	writeResponse(w, r, http.StatusOK, results)
+}
+
+// batchResult reports the outcome of one event of a batch
+func (a *App) batchResult(w http.ResponseWriter, r *http.Request, result submission) model.BatchResult {
+	batchResult := model.BatchResult{EventID: result.eventID, HTTPStatus: result.status}
+	switch {
+	case result.status < 300:
+		batchResult.Status = model.BatchAccepted
+	case result.status == http.StatusConflict:
+		batchResult.Status = model.BatchDuplicate
+	case result.status < 500 && result.status != http.StatusTooManyRequests:
+		batchResult.Status = model.BatchInvalid
+	default:
+		batchResult.Status = model.BatchUnavailable
+	}
+	if result.err != nil {
+		message, locale := a.messages.message(r.Header.Get("Accept-Language"), result.err)
+		w.Header().Set("Content-Language", locale)
+		batchResult.Error = &model.ErrorResponse{Code: string(result.err.code), Message: message}
+	}
+	return batchResult
+}
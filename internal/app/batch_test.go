@@ -0,0 +1,91 @@
+package app
+
+import (
+	"encoding/json"
+	"event-service/internal/model"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func postBatch(application *App, body string) (*httptest.ResponseRecorder, []model.BatchResult) {
+	req := httptest.NewRequest(http.MethodPost, "/events/batch", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	application.handleBatch(rec, req)
+	var results []model.BatchResult
+	json.Unmarshal(rec.Body.Bytes(), &results)
+	return rec, results
+}
+
+func TestBatchMixedResults(t *testing.T) {
+	application := newTestApp(t, Config{WorkerMode: "manual", MaxBatchSize: 10})
+	application.store.Save(&model.Event{EventID: "evt_existing", Status: model.StatusAccepted})
+
+	rec, results := postBatch(application, `[
+		{"event_id": "evt_1", "payload": {"n": 1}},
+		{"event_id": "evt_1", "payload": {"n": 2}},
+		{"event_id": "evt_existing", "payload": {}},
+		{"event_id": "", "payload": {}},
+		{"event_id": "evt_2", "delay_ms": -1},
+		{"event_id": 42},
+		{"event_id": "evt_3"}
+	]`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	expected := []struct {
+		eventID    string
+		status     string
+		httpStatus int
+		code       string
+	}{
+		{"evt_1", model.BatchAccepted, http.StatusAccepted, ""},
+		{"evt_1", model.BatchDuplicate, http.StatusConflict, ""},
+		{"evt_existing", model.BatchDuplicate, http.StatusConflict, ""},
+		{"", model.BatchInvalid, http.StatusBadRequest, string(errEventIDRequired)},
+		{"evt_2", model.BatchInvalid, http.StatusBadRequest, string(errNegativeDelay)},
+		{"", model.BatchInvalid, http.StatusBadRequest, string(errInvalidRequestBody)},
+		{"evt_3", model.BatchAccepted, http.StatusAccepted, ""},
+	}
+	if len(results) != len(expected) {
+		t.Fatalf("Expected %d results, got %+v", len(expected), results)
+	}
+	for i, want := range expected {
+		got := results[i]
+		code := ""
+		if got.Error != nil {
+			code = got.Error.Code
+		}
+		if got.EventID != want.eventID || got.Status != want.status || got.HTTPStatus != want.httpStatus || code != want.code {
+			t.Errorf("Result %d: expected %+v, got %+v (error code %q)", i, want, got, code)
+		}
+	}
+
+	// The first copy of evt_1 is the one stored
+	if event, _ := application.store.Get("evt_1"); string(event.Payload) != `{"n": 1}` {
+		t.Errorf("Expected the first evt_1 to be stored, got %s", event.Payload)
+	}
+	if _, exists := application.store.Get("evt_3"); !exists {
+		t.Error("Expected evt_3 to be stored despite the failures before it")
+	}
+}
+
+func TestBatchLimits(t *testing.T) {
+	application := newTestApp(t, Config{WorkerMode: "manual", MaxBatchSize: 2})
+
+	if rec, _ := postBatch(application, `[{"event_id": "a"}, {"event_id": "b"}, {"event_id": "c"}]`); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for a batch over MAX_BATCH_SIZE, got %d", rec.Code)
+	}
+	if _, exists := application.store.Get("a"); exists {
+		t.Error("Expected nothing of a rejected batch to be stored")
+	}
+	if rec, _ := postBatch(application, `{"event_id": "a"}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for a body that isn't an array, got %d", rec.Code)
+	}
+	if rec, results := postBatch(application, `[]`); rec.Code != http.StatusOK || results == nil || len(results) != 0 {
+		t.Errorf("Expected 200 with no results for an empty batch, got %d %+v", rec.Code, results)
+	}
+}
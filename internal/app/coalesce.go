@@ -0,0 +1,131 @@
+package app
+
+import (
+	"encoding/json"
+	"event-service/internal/model"
+	"event-service/internal/store"
+	"log"
+	"sync"
+	"time"
+)
+
+// Coalescing merge strategies for fields present in several payloads
+const (
+	coalesceFirstWins = "first"
+	coalesceLastWins  = "last"
+)
+
+// coalescer collapses events that share a dedup key within a short window
+// into the first one. The first event is stored right away but only
+// enqueued once the window ends; later events with the same key merge their
+// payload into it instead of being rejected, so the group is processed once.
+type coalescer struct {
+	store    *store.Store
+	enqueue  func(*model.Event)
+	window   time.Duration
+	lastWins bool
+
+	mu      sync.Mutex
+	pending map[string]*coalescing
+}
+
+// coalescing is an event held open for merges until its timer fires
+type coalescing struct {
+	event *model.Event
+	timer *time.Timer
+}
+
+func newCoalescer(st *store.Store, enqueue func(*model.Event), window time.Duration, merge string) *coalescer {
+	if merge != coalesceFirstWins && merge != coalesceLastWins {
+		log.Printf("Unknown coalesce merge strategy %q, using default: %s", merge, coalesceFirstWins)
+		merge = coalesceFirstWins
+	}
+	return &coalescer{
+		store:    st,
+		enqueue:  enqueue,
+		window:   window,
+		lastWins: merge == coalesceLastWins,
+		pending:  make(map[string]*coalescing),
+	}
+}
+
+// submit merges the event into a pending event with the same dedup key and
+// returns that event's ID. Otherwise it stores the event with save and, if
+// saved, holds it open for the window. Both happen under one lock, so a
+// concurrent duplicate can't slip in between.
+func (c *coalescer) submit(event *model.Event, save func() (bool, error)) (mergedInto string, saved bool, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if held, ok := c.pending[event.DedupKey]; ok {
+		stored, exists := c.store.Get(held.event.EventID)
+		if exists {
+			payload := mergePayloads(stored.Payload, event.Payload, c.lastWins)
+			if err := c.store.SetPayload(held.event.EventID, payload); err != nil {
+				return "", false, err
+			}
+			return held.event.EventID, false, nil
+		}
+	}
+
+	saved, err = save()
+	if err != nil || !saved {
+		return "", saved, err
+	}
+	key := event.DedupKey
+	c.pending[key] = &coalescing{
+		event: event,
+		timer: time.AfterFunc(c.window, func() { c.flush(key) }),
+	}
+	return "", true, nil
+}
+
+// flush closes the window for a dedup key and enqueues its event
+func (c *coalescer) flush(key string) {
+	c.mu.Lock()
+	held, ok := c.pending[key]
+	delete(c.pending, key)
+	c.mu.Unlock()
+	if ok {
+		c.enqueue(held.event)
+	}
+}
+
+// flushAll closes every open window right away, e.g. on shutdown
+func (c *coalescer) flushAll() {
+	c.mu.Lock()
+	held := make([]*model.Event, 0, len(c.pending))
+	for key, pending := range c.pending {
+		pending.timer.Stop()
+		held = append(held, pending.event)
+		delete(c.pending, key)
+	}
+	c.mu.Unlock()
+	for _, event := range held {
+		c.enqueue(event)
+	}
+}
+
+// mergePayloads merges the top-level fields of two object payloads. Fields
+// in both keep the earlier value unless lastWins is set. Payloads that
+// aren't both objects can't be merged field by field, so one of them is
+// kept whole.
+func mergePayloads(earlier, later json.RawMessage, lastWins bool) json.RawMessage {
+	var base, update map[string]json.RawMessage
+	if json.Unmarshal(earlier, &base) != nil || json.Unmarshal(later, &update) != nil || base == nil || update == nil {
+		if lastWins {
+			return later
+		}
+		return earlier
+	}
+	for field, value := range update {
+		if _, exists := base[field]; !exists || lastWins {
+			base[field] = value
+		}
+	}
+	merged, err := json.Marshal(base)
+	if err != nil {
+		return earlier
+	}
+	return merged
+}
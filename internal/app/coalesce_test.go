@@ -0,0 +1,69 @@
+package app
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMergePayloads(t *testing.T) {
+	earlier := json.RawMessage(`{"a": 1, "b": 1}`)
+	later := json.RawMessage(`{"b": 2, "c": 2}`)
+
+	if merged := string(mergePayloads(earlier, later, false)); merged != `{"a":1,"b":1,"c":2}` {
+		t.Errorf("Expected first-wins merge, got %s", merged)
+	}
+	if merged := string(mergePayloads(earlier, later, true)); merged != `{"a":1,"b":2,"c":2}` {
+		t.Errorf("Expected last-wins merge, got %s", merged)
+	}
+	if merged := string(mergePayloads(json.RawMessage(`[1]`), later, false)); merged != `[1]` {
+		t.Errorf("Expected a non-object payload to be kept whole, got %s", merged)
+	}
+}
+
+func TestCoalesceWithinWindow(t *testing.T) {
+	application := newTestApp(t, Config{
+		WorkerMode:       "manual",
+		DedupKeyPaths:    []string{"order_id"},
+		CoalesceWindowMs: 50,
+		CoalesceMerge:    "last",
+	})
+
+	submit := func(id, payload string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		body := strings.NewReader(`{"event_id": "` + id + `", "payload": ` + payload + `}`)
+		application.handleEvents(rec, httptest.NewRequest(http.MethodPost, "/events", body))
+		return rec
+	}
+
+	if rec := submit("first", `{"order_id": 7, "status": "created"}`); rec.Code != http.StatusAccepted {
+		t.Fatalf("Expected 202, got %d", rec.Code)
+	}
+	rec := submit("second", `{"order_id": 7, "status": "paid"}`)
+	if rec.Code != http.StatusAccepted || rec.Header().Get("X-Coalesced-Into") != "first" {
+		t.Fatalf("Expected 202 coalesced into first, got %d %q", rec.Code, rec.Header().Get("X-Coalesced-Into"))
+	}
+
+	event, _ := application.store.Get("first")
+	if string(event.Payload) != `{"order_id":7,"status":"paid"}` {
+		t.Errorf("Expected the last status to win, got %s", event.Payload)
+	}
+	if _, exists := application.store.Get("second"); exists {
+		t.Error("Expected the coalesced event not to be stored separately")
+	}
+	if depth := application.worker.QueueDepth(); depth != 0 {
+		t.Errorf("Expected nothing queued during the window, got %d", depth)
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	if depth := application.worker.QueueDepth(); depth != 1 {
+		t.Errorf("Expected the merged event to be queued once after the window, got %d", depth)
+	}
+	// Outside the window the key is an ordinary duplicate again
+	if rec := submit("third", `{"order_id": 7}`); rec.Code != http.StatusConflict {
+		t.Errorf("Expected 409 after the window, got %d", rec.Code)
+	}
+}
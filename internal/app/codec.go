@@ -0,0 +1,111 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// codec encodes and decodes request and response bodies in one wire format
+type codec interface {
+	contentType() string
+	decode(r io.Reader, v interface{}) error
+	encode(w io.Writer, v interface{}) error
+}
+
+// jsonCodec is the default wire format
+type jsonCodec struct{}
+
+func (jsonCodec) contentType() string { return "application/json" }
+
+func (jsonCodec) decode(r io.Reader, v interface{}) error {
+	return json.NewDecoder(r).Decode(v)
+}
+
+func (jsonCodec) encode(w io.Writer, v interface{}) error {
+	return json.NewEncoder(w).Encode(v)
+}
+
+// msgpackCodec is a compact binary alternative for high-volume clients.
+//
+// Values are bridged through their JSON form so the model types and their
+// json tags (including raw payloads) behave exactly as they do for JSON.
+type msgpackCodec struct{}
+
+func (msgpackCodec) contentType() string { return "application/msgpack" }
+
+func (msgpackCodec) decode(r io.Reader, v interface{}) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	value, err := unmarshalMsgpack(data)
+	if err != nil {
+		return err
+	}
+	bridged, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(bridged, v)
+}
+
+func (msgpackCodec) encode(w io.Writer, v interface{}) error {
+	bridged, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	dec := json.NewDecoder(bytes.NewReader(bridged))
+	dec.UseNumber()
+	var value interface{}
+	if err := dec.Decode(&value); err != nil {
+		return err
+	}
+	_, err = w.Write(marshalMsgpack(nil, value))
+	return err
+}
+
+// codecForMediaType returns the codec for a Content-Type or Accept media
+// type, and false if the type is not supported
+func codecForMediaType(mediaType string) (codec, bool) {
+	switch mediaType {
+	case "application/msgpack", "application/x-msgpack":
+		return msgpackCodec{}, true
+	case "application/json":
+		return jsonCodec{}, true
+	}
+	return nil, false
+}
+
+// requestCodec picks the codec for the request body from its Content-Type,
+// treating anything unrecognized as JSON
+func requestCodec(r *http.Request) codec {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if c, ok := codecForMediaType(mediaType); ok {
+		return c
+	}
+	return jsonCodec{}
+}
+
+// responseCodec picks the first supported format listed in the Accept
+// header, falling back to JSON
+func responseCodec(r *http.Request) codec {
+	for _, accepted := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, _, _ := mime.ParseMediaType(strings.TrimSpace(accepted))
+		if c, ok := codecForMediaType(mediaType); ok {
+			return c
+		}
+	}
+	return jsonCodec{}
+}
+
+// writeResponse encodes v in the format negotiated from the Accept header
+func writeResponse(w http.ResponseWriter, r *http.Request, status int, v interface{}) {
+	c := responseCodec(r)
+	w.Header().Set("Content-Type", c.contentType())
+	w.WriteHeader(status)
+	c.encode(w, v)
+}
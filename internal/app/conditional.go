@@ -0,0 +1,45 @@
+package app
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// listValidators sets ETag and Last-Modified for a list response built from
+// the store at the given version and reports whether the client's cached
+// copy is still current. The ETag includes the store's epoch since update
+// sequences restart with every instance, and the response format since
+// JSON, MessagePack and CSV bodies of the same list differ.
+func listValidators(w http.ResponseWriter, r *http.Request, epoch string, seq uint64, modifiedAt time.Time) bool {
+	format := strings.TrimPrefix(responseCodec(r).contentType(), "application/")
+	if wantsCSV(r) {
+		format = "csv"
+	}
+	etag := fmt.Sprintf(`"%s-%d-%s"`, epoch, seq, format)
+	w.Header().Set("ETag", etag)
+	w.Header().Add("Vary", "Accept")
+	// Let browsers cache the list but revalidate it on every poll
+	w.Header().Set("Cache-Control", "no-cache")
+	if !modifiedAt.IsZero() {
+		w.Header().Set("Last-Modified", modifiedAt.UTC().Format(http.TimeFormat))
+	}
+
+	// If-None-Match takes precedence over If-Modified-Since (RFC 9110 13.2.2)
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		for _, candidate := range strings.Split(inm, ",") {
+			candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+			if candidate == etag || candidate == "*" {
+				return true
+			}
+		}
+		return false
+	}
+	if ims := r.Header.Get("If-Modified-Since"); ims != "" && !modifiedAt.IsZero() {
+		since, err := http.ParseTime(ims)
+		// Last-Modified has second precision
+		return err == nil && !modifiedAt.Truncate(time.Second).After(since)
+	}
+	return false
+}
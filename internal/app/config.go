@@ -0,0 +1,102 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// knownEnvs are the accepted values of ENV; the Terraform deployment uses
+// "production"
+var knownEnvs = []string{"dev", "test", "staging", "prod", "production"}
+
+// knownStoreBackends are the accepted values of STORE_BACKEND
+var knownStoreBackends = []string{"memory", "sqlite", "redis"}
+
+// knownEventIDWhitespace are the accepted values of EVENT_ID_WHITESPACE
+var knownEventIDWhitespace = []string{"reject", "trim"}
+
+// Validate checks the configuration for values the service can't run with,
+// including environment variables LoadConfig could not parse and replaced
+// with defaults. It reports every problem at once.
+func (c Config) Validate() error {
+	problems := append([]string(nil), c.invalidEnv...)
+
+	if err := validatePort(c.Port); err != nil {
+		problems = append(problems, "PORT "+err.Error())
+	}
+	if c.AdminPort != "" {
+		if err := validatePort(c.AdminPort); err != nil {
+			problems = append(problems, "ADMIN_PORT "+err.Error())
+		}
+	}
+	if !slices.Contains(knownEnvs, c.Env) {
+		problems = append(problems, fmt.Sprintf("ENV %q is not one of %s", c.Env, strings.Join(knownEnvs, ", ")))
+	}
+	if c.StoreBackend != "" && !slices.Contains(knownStoreBackends, c.StoreBackend) {
+		problems = append(problems, fmt.Sprintf("STORE_BACKEND %q is not one of %s", c.StoreBackend, strings.Join(knownStoreBackends, ", ")))
+	}
+	if c.EventIDWhitespace != "" && !slices.Contains(knownEventIDWhitespace, c.EventIDWhitespace) {
+		problems = append(problems, fmt.Sprintf("EVENT_ID_WHITESPACE %q is not one of %s", c.EventIDWhitespace, strings.Join(knownEventIDWhitespace, ", ")))
+	}
+	if c.ShutdownHandoff && (c.StoreBackend == "" || c.StoreBackend == "memory") {
+		// The handed-off events would only exist in the memory of the
+		// stopped instance
+		problems = append(problems, "SHUTDOWN_HANDOFF requires a persistent STORE_BACKEND (sqlite or redis)")
+	}
+	if c.RedisUsername != "" && c.RedisPassword == "" {
+		problems = append(problems, "REDIS_USERNAME requires REDIS_PASSWORD")
+	}
+
+	for _, setting := range []struct {
+		name  string
+		value int
+	}{
+		{"REQUEST_TIMEOUT_MS", c.RequestTimeoutMs},
+		{"BODY_READ_TIMEOUT_MS", c.BodyReadTimeoutMs},
+		{"SHUTDOWN_TIMEOUT_MS", c.ShutdownTimeoutMs},
+		{"PROCESSING_DELAY_MS", c.ProcessingDelayMs},
+		{"AUTO_SHUTDOWN_IDLE_MS", c.AutoShutdownIdleMs},
+		{"ERROR_RATE_WINDOW_MS", c.ErrorRateWindowMs},
+		{"STORE_RETRY_BACKOFF_MS", c.StoreRetryBackoffMs},
+		{"RETRY_BACKOFF_MS", c.RetryBackoffMs},
+		{"RETRY_BACKOFF_MAX_MS", c.RetryBackoffMaxMs},
+		{"COALESCE_WINDOW_MS", c.CoalesceWindowMs},
+		{"ENRICHMENT_TIMEOUT_MS", c.EnrichmentTimeoutMs},
+		{"ENRICHMENT_CACHE_TTL_MS", c.EnrichmentCacheTTLMs},
+		{"CALLBACK_TIMEOUT_MS", c.CallbackTimeoutMs},
+		{"CALLBACK_BACKOFF_MS", c.CallbackBackoffMs},
+		{"IDEMPOTENCY_TIMEOUT_MS", c.IdempotencyTimeoutMs},
+		{"IDEMPOTENCY_TTL_MS", c.IdempotencyTTLMs},
+		{"PROCESSING_TIMEOUT_MS", c.ProcessingTimeoutMs},
+		{"READ_SNAPSHOT_INTERVAL_MS", c.ReadSnapshotIntervalMs},
+		{"ARCHIVE_INTERVAL_MS", c.ArchiveIntervalMs},
+		{"ARCHIVE_TIMEOUT_MS", c.ArchiveTimeoutMs},
+	} {
+		if setting.value < 0 {
+			problems = append(problems, fmt.Sprintf("%s must not be negative, got %d", setting.name, setting.value))
+		}
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+	return errors.New("invalid configuration: " + strings.Join(problems, "; "))
+}
+
+// validatePort checks that port is a TCP port number
+func validatePort(port string) error {
+	if port == "" {
+		return errors.New("must not be empty")
+	}
+	n, err := strconv.Atoi(port)
+	if err != nil {
+		return fmt.Errorf("%q is not a number", port)
+	}
+	if n < 1 || n > 65535 {
+		return fmt.Errorf("%d is out of range 1-65535", n)
+	}
+	return nil
+}
@@ -0,0 +1,175 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValidateConfig(t *testing.T) {
+	valid := Config{Port: "8080", Env: "dev", ProcessingDelayMs: 1000}
+	if err := valid.Validate(); err != nil {
+		t.Fatalf("Expected a valid config, got %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		modify   func(*Config)
+		problems []string
+	}{
+		{"empty port", func(c *Config) { c.Port = "" }, []string{"PORT must not be empty"}},
+		{"non-numeric port", func(c *Config) { c.Port = "http" }, []string{`PORT "http" is not a number`}},
+		{"port out of range", func(c *Config) { c.Port = "70000" }, []string{"PORT 70000 is out of range"}},
+		{"admin port", func(c *Config) { c.AdminPort = "0" }, []string{"ADMIN_PORT 0 is out of range"}},
+		{"unknown env", func(c *Config) { c.Env = "prd" }, []string{`ENV "prd" is not one of`}},
+		{"unknown event_id whitespace handling", func(c *Config) { c.EventIDWhitespace = "strip" }, []string{`EVENT_ID_WHITESPACE "strip" is not one of reject, trim`}},
+		{"unknown store backend", func(c *Config) { c.StoreBackend = "postgres" }, []string{`STORE_BACKEND "postgres" is not one of`}},
+		{"handoff without persistence", func(c *Config) { c.ShutdownHandoff = true }, []string{"SHUTDOWN_HANDOFF requires a persistent STORE_BACKEND"}},
+		{"redis username without password", func(c *Config) { c.RedisUsername = "events" }, []string{"REDIS_USERNAME requires REDIS_PASSWORD"}},
+		{"negative delay", func(c *Config) { c.ProcessingDelayMs = -1 }, []string{"PROCESSING_DELAY_MS must not be negative"}},
+		{"several problems", func(c *Config) {
+			c.Port = ""
+			c.Env = ""
+			c.RetryBackoffMs = -5
+			c.ShutdownTimeoutMs = -1
+		}, []string{"PORT must not be empty", `ENV "" is not one of`, "RETRY_BACKOFF_MS must not be negative", "SHUTDOWN_TIMEOUT_MS must not be negative"}},
+	}
+
+	for _, tt := range tests {
+		config := valid
+		tt.modify(&config)
+		err := config.Validate()
+		if err == nil {
+			t.Errorf("%s: expected an error", tt.name)
+			continue
+		}
+		for _, problem := range tt.problems {
+			if !strings.Contains(err.Error(), problem) {
+				t.Errorf("%s: expected error to mention %q, got %v", tt.name, problem, err)
+			}
+		}
+	}
+}
+
+func TestValidateReportsUnparsableEnv(t *testing.T) {
+	t.Setenv("PROCESSING_DELAY_MS", "abc")
+	t.Setenv("METRICS_ENABLED", "maybe")
+	t.Setenv("PORT", "8080")
+	t.Setenv("ENV", "dev")
+
+	config := LoadConfig()
+	if config.ProcessingDelayMs != 1000 {
+		t.Errorf("Expected the default delay to be kept, got %d", config.ProcessingDelayMs)
+	}
+	err := config.Validate()
+	if err == nil {
+		t.Fatal("Expected unparsable variables to fail validation")
+	}
+	for _, problem := range []string{`PROCESSING_DELAY_MS="abc" is not an integer`, `METRICS_ENABLED="maybe" is not a boolean`} {
+		if !strings.Contains(err.Error(), problem) {
+			t.Errorf("Expected error to mention %q, got %v", problem, err)
+		}
+	}
+}
+
+func TestLoadConfigFile(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		content string
+	}{
+		{"config.yaml", `
+# staging settings
+env: staging
+port: "9191"
+processing_delay_ms: 250 # faster than default
+max_retries: 7
+cors_allowed_origins:
+  - https://a.example.com
+  - 'https://b.example.com'
+processing_timeout_by_type:
+  export: 60000
+  import: 1000
+dedup_key_paths: [type, order.id]
+`},
+		{"config.json", `{
+	"env": "staging",
+	"port": 9191,
+	"processing_delay_ms": 250,
+	"MAX_RETRIES": 7,
+	"cors_allowed_origins": ["https://a.example.com", "https://b.example.com"],
+	"processing_timeout_by_type": {"export": 60000, "import": 1000},
+	"dedup_key_paths": ["type", "order.id"]
+}`},
+	} {
+		path := filepath.Join(t.TempDir(), tt.name)
+		if err := os.WriteFile(path, []byte(tt.content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		t.Setenv("CONFIG_FILE", path)
+		t.Setenv("PORT", "")
+		t.Setenv("ENV", "")
+		t.Setenv("PROCESSING_DELAY_MS", "")
+		// The environment overrides the file
+		t.Setenv("MAX_RETRIES", "2")
+
+		config := LoadConfig()
+		if err := config.Validate(); err != nil {
+			t.Fatalf("%s: expected a valid config, got %v", tt.name, err)
+		}
+		if config.Env != "staging" || config.Port != "9191" || config.ProcessingDelayMs != 250 {
+			t.Errorf("%s: expected file values, got env %q, port %q, delay %d", tt.name, config.Env, config.Port, config.ProcessingDelayMs)
+		}
+		if config.MaxRetries != 2 {
+			t.Errorf("%s: expected MAX_RETRIES from the environment, got %d", tt.name, config.MaxRetries)
+		}
+		if config.RetryBackoffMs != 1000 {
+			t.Errorf("%s: expected the default for settings missing from both, got %d", tt.name, config.RetryBackoffMs)
+		}
+		if strings.Join(config.CORSAllowedOrigins, " ") != "https://a.example.com https://b.example.com" {
+			t.Errorf("%s: expected both origins, got %v", tt.name, config.CORSAllowedOrigins)
+		}
+		if config.ProcessingTimeoutByTypeMs["export"] != 60000 || config.ProcessingTimeoutByTypeMs["import"] != 1000 {
+			t.Errorf("%s: expected per-type timeouts, got %v", tt.name, config.ProcessingTimeoutByTypeMs)
+		}
+		if strings.Join(config.DedupKeyPaths, " ") != "type order.id" {
+			t.Errorf("%s: expected dedup key paths, got %v", tt.name, config.DedupKeyPaths)
+		}
+	}
+}
+
+func TestConfigFileUnknownKeys(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	os.WriteFile(path, []byte("port: 8080\nprocesing_delay_ms: 10\nenv: dev\n"), 0o600)
+	t.Setenv("CONFIG_FILE", path)
+	t.Setenv("PORT", "")
+	t.Setenv("ENV", "")
+
+	err := LoadConfig().Validate()
+	if err == nil || !strings.Contains(err.Error(), "procesing_delay_ms is not a known setting") {
+		t.Errorf("Expected the misspelled key to be reported, got %v", err)
+	}
+}
+
+func TestConfigFileErrors(t *testing.T) {
+	for name, content := range map[string]string{
+		"missing.yaml":  "",
+		"config.toml":   "port = 8080",
+		"bad.json":      `{"port": `,
+		"nested.json":   `{"port": {"a": {"b": 1}}}`,
+		"indent.yaml":   "  port: 8080",
+		"anchor.yaml":   "port: &p 8080",
+		"noColon.yaml":  "port 8080",
+		"tabs.yaml":     "origins:\n\t- a",
+		"mixed.yaml":    "origins:\n  - a\n  b: c",
+		"duplicate.yml": "port: 1\nport: 2",
+	} {
+		path := filepath.Join(t.TempDir(), name)
+		if name != "missing.yaml" {
+			os.WriteFile(path, []byte(content), 0o600)
+		}
+		if _, err := loadConfigFile(path); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
@@ -0,0 +1,272 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// configFile holds the settings read from CONFIG_FILE, keyed by the name of
+// the environment variable each one stands in for and in the same format:
+// lists are comma-separated and maps are "key=value" pairs
+type configFile struct {
+	values map[string]string
+	used   map[string]bool
+}
+
+// loadConfigFile reads a JSON (.json) or YAML (.yaml, .yml) config file.
+// Keys are environment variable names, in any case.
+func loadConfigFile(path string) (*configFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var values map[string]string
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		values, err = parseJSONConfig(data)
+	case ".yaml", ".yml":
+		values, err = parseYAMLConfig(data)
+	default:
+		return nil, fmt.Errorf("unsupported config file type %q, use .json, .yaml or .yml", filepath.Ext(path))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	file := &configFile{values: make(map[string]string, len(values)), used: make(map[string]bool)}
+	for key, value := range values {
+		file.values[strings.ToUpper(key)] = value
+	}
+	return file, nil
+}
+
+// lookup returns the file's value for an environment variable and marks
+// the key as known
+func (f *configFile) lookup(key string) string {
+	f.used[key] = true
+	return f.values[key]
+}
+
+// unknownKeys returns the keys that no setting looked up, in order
+func (f *configFile) unknownKeys() []string {
+	var unknown []string
+	for key := range f.values {
+		if !f.used[key] {
+			unknown = append(unknown, key)
+		}
+	}
+	sort.Strings(unknown)
+	return unknown
+}
+
+// parseJSONConfig reads a JSON object whose values are scalars, arrays of
+// scalars, or objects of scalars
+func parseJSONConfig(data []byte) (map[string]string, error) {
+	var raw map[string]interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	values := make(map[string]string, len(raw))
+	for key, value := range raw {
+		var err error
+		switch v := value.(type) {
+		case []interface{}:
+			entries := make([]string, len(v))
+			for i, entry := range v {
+				if entries[i], err = jsonScalar(entry); err != nil {
+					return nil, fmt.Errorf("%s: %w", key, err)
+				}
+			}
+			values[key] = strings.Join(entries, ",")
+		case map[string]interface{}:
+			entries := make(map[string]string, len(v))
+			for name, entry := range v {
+				if entries[name], err = jsonScalar(entry); err != nil {
+					return nil, fmt.Errorf("%s: %w", key, err)
+				}
+			}
+			values[key] = joinPairs(entries)
+		default:
+			if values[key], err = jsonScalar(value); err != nil {
+				return nil, fmt.Errorf("%s: %w", key, err)
+			}
+		}
+	}
+	return values, nil
+}
+
+// jsonScalar formats a decoded JSON scalar like the environment variable
+func jsonScalar(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	}
+	return "", errors.New("nested values are not supported")
+}
+
+// joinPairs formats a map as sorted "key=value" pairs
+func joinPairs(entries map[string]string) string {
+	pairs := make([]string, 0, len(entries))
+	for name, value := range entries {
+		pairs = append(pairs, name+"="+value)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+// parseYAMLConfig reads the subset of YAML a flat config needs: top-level
+// "key: value" lines, where a value is a scalar (plain or quoted), a flow
+// list like [a, b], or an indented block of "- item" or "name: value" lines.
+// Comments and blank lines are skipped.
+func parseYAMLConfig(data []byte) (map[string]string, error) {
+	values := make(map[string]string)
+	var (
+		blockKey string
+		list     []string
+		pairs    map[string]string
+	)
+	closeBlock := func() {
+		switch {
+		case list != nil:
+			values[blockKey] = strings.Join(list, ",")
+		case pairs != nil:
+			values[blockKey] = joinPairs(pairs)
+		}
+		blockKey, list, pairs = "", nil, nil
+	}
+
+	for n, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimRight(stripYAMLComment(line), " \t\r")
+		if strings.TrimSpace(line) == "" || line == "---" {
+			continue
+		}
+		if strings.HasPrefix(line, "\t") {
+			return nil, fmt.Errorf("line %d: tabs are not allowed for indentation", n+1)
+		}
+
+		trimmed := strings.TrimLeft(line, " ")
+		if trimmed != line {
+			// Indented: an entry of the block under blockKey
+			if blockKey == "" {
+				return nil, fmt.Errorf("line %d: unexpected indentation", n+1)
+			}
+			if item, ok := strings.CutPrefix(trimmed, "- "); ok && pairs == nil {
+				value, err := yamlScalar(item)
+				if err != nil {
+					return nil, fmt.Errorf("line %d: %w", n+1, err)
+				}
+				list = append(list, value)
+				continue
+			}
+			name, value, ok := cutYAMLKey(trimmed)
+			if !ok || list != nil {
+				return nil, fmt.Errorf("line %d: expected \"- item\" or \"name: value\"", n+1)
+			}
+			scalar, err := yamlScalar(value)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", n+1, err)
+			}
+			if pairs == nil {
+				pairs = make(map[string]string)
+			}
+			pairs[name] = scalar
+			continue
+		}
+
+		closeBlock()
+		key, value, ok := cutYAMLKey(line)
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected \"key: value\"", n+1)
+		}
+		if _, exists := values[key]; exists {
+			return nil, fmt.Errorf("line %d: duplicate key %q", n+1, key)
+		}
+		if value == "" {
+			// Either an empty value or the start of a block
+			values[key] = ""
+			blockKey = key
+			continue
+		}
+		if strings.HasPrefix(value, "[") && strings.HasSuffix(value, "]") {
+			var entries []string
+			for _, entry := range strings.Split(value[1:len(value)-1], ",") {
+				if entry = strings.TrimSpace(entry); entry == "" {
+					continue
+				}
+				scalar, err := yamlScalar(entry)
+				if err != nil {
+					return nil, fmt.Errorf("line %d: %w", n+1, err)
+				}
+				entries = append(entries, scalar)
+			}
+			values[key] = strings.Join(entries, ",")
+			continue
+		}
+		scalar, err := yamlScalar(value)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n+1, err)
+		}
+		values[key] = scalar
+	}
+	closeBlock()
+	return values, nil
+}
+
+// cutYAMLKey splits "key: value" into its trimmed parts
+func cutYAMLKey(line string) (key, value string, ok bool) {
+	key, value, ok = strings.Cut(line, ":")
+	key = strings.TrimSpace(key)
+	if !ok || key == "" || (value != "" && !strings.HasPrefix(value, " ")) {
+		return "", "", false
+	}
+	return key, strings.TrimSpace(value), true
+}
+
+// yamlScalar unquotes a single- or double-quoted scalar; plain scalars are
+// returned as they are, with null and ~ meaning empty
+func yamlScalar(value string) (string, error) {
+	value = strings.TrimSpace(value)
+	switch {
+	case len(value) >= 2 && value[0] == '"' && value[len(value)-1] == '"':
+		return strconv.Unquote(value)
+	case len(value) >= 2 && value[0] == '\'' && value[len(value)-1] == '\'':
+		return strings.ReplaceAll(value[1:len(value)-1], "''", "'"), nil
+	case value == "null" || value == "~":
+		return "", nil
+	case strings.HasPrefix(value, "{") || strings.HasPrefix(value, "&") || strings.HasPrefix(value, "*") || strings.HasPrefix(value, "|") || strings.HasPrefix(value, ">"):
+		return "", fmt.Errorf("unsupported YAML value %q", value)
+	}
+	return value, nil
+}
+
+// stripYAMLComment removes a "#" comment that starts the line or follows
+// whitespace, outside of quotes
+func stripYAMLComment(line string) string {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		switch c := line[i]; {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#' && (i == 0 || line[i-1] == ' ' || line[i-1] == '\t'):
+			return line[:i]
+		}
+	}
+	return line
+}
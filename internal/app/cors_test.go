@@ -0,0 +1,75 @@
+package app
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCORS(t *testing.T) {
+	application := newTestApp(t, Config{WorkerMode: "manual", CORSAllowedOrigins: []string{"https://app.example.com"}, APIKey: "s3cret"})
+	application.worker.Start()
+	defer application.worker.Stop(context.Background())
+	handler := application.routes()
+
+	send := func(method, path, origin string, headers map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(`{"event_id": "evt_1"}`))
+		req.Header.Set("Origin", origin)
+		for name, value := range headers {
+			req.Header.Set(name, value)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+	preflight := map[string]string{"Access-Control-Request-Method": "POST", "Access-Control-Request-Headers": "Content-Type, X-API-Key"}
+
+	// Preflight from an allowed origin is answered without the API key
+	rec := send(http.MethodOptions, "/events", "https://app.example.com", preflight)
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("Expected 204 for an allowed preflight, got %d", rec.Code)
+	}
+	if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "https://app.example.com" {
+		t.Errorf("Expected the origin to be reflected, got %q", got)
+	}
+	if !strings.Contains(rec.Header().Get("Access-Control-Allow-Methods"), "POST") || !strings.Contains(rec.Header().Get("Access-Control-Allow-Headers"), "X-API-Key") {
+		t.Errorf("Expected the allowed methods and headers, got %v", rec.Header())
+	}
+
+	rec = send(http.MethodOptions, "/events", "https://evil.example.com", preflight)
+	if rec.Code != http.StatusForbidden || rec.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Errorf("Expected 403 without CORS headers for a disallowed preflight, got %d %v", rec.Code, rec.Header())
+	}
+
+	// Actual requests
+	rec = send(http.MethodPost, "/events", "https://app.example.com", map[string]string{"X-API-Key": "s3cret"})
+	if rec.Code != http.StatusAccepted || rec.Header().Get("Access-Control-Allow-Origin") != "https://app.example.com" {
+		t.Errorf("Expected 202 with CORS headers, got %d %v", rec.Code, rec.Header())
+	}
+	if !strings.Contains(rec.Header().Get("Access-Control-Expose-Headers"), "X-Request-ID") {
+		t.Errorf("Expected X-Request-ID to be exposed, got %q", rec.Header().Get("Access-Control-Expose-Headers"))
+	}
+	rec = send(http.MethodGet, "/events", "https://evil.example.com", nil)
+	if rec.Code != http.StatusOK || rec.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Errorf("Expected no CORS headers for a disallowed origin, got %d %v", rec.Code, rec.Header())
+	}
+
+	// The dashboard is not part of the API
+	rec = send(http.MethodGet, "/", "https://app.example.com", nil)
+	if rec.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Error("Expected no CORS headers on the dashboard")
+	}
+}
+
+func TestCORSWildcard(t *testing.T) {
+	application := newTestApp(t, Config{CORSAllowedOrigins: []string{"*"}})
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/events/count", nil)
+	req.Header.Set("Origin", "https://anywhere.example.com")
+	application.routes().ServeHTTP(rec, req)
+	if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "https://anywhere.example.com" {
+		t.Errorf("Expected the origin to be reflected instead of *, got %q", got)
+	}
+}
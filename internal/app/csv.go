@@ -0,0 +1,73 @@
+package app
+
+import (
+	"encoding/csv"
+	"event-service/internal/model"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// csvColumns is the header row of the event list in CSV. The payload comes
+// last as raw JSON since it is the only column that may need quoting.
+var csvColumns = []string{"event_id", "status", "created_at", "processed_at", "payload"}
+
+// listFormat checks the ?format= parameter of GET /events, which may be
+// "json" or "csv" and overrides the Accept header
+func listFormat(r *http.Request) *apiError {
+	switch r.URL.Query().Get("format") {
+	case "", "json", "csv":
+		return nil
+	}
+	return newAPIError(errInvalidFormat)
+}
+
+// wantsCSV reports whether the event list was requested as CSV, with
+// ?format=csv or an Accept header listing text/csv before any other
+// supported format
+func wantsCSV(r *http.Request) bool {
+	if format := r.URL.Query().Get("format"); format != "" {
+		return format == "csv"
+	}
+	for _, accepted := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, _, _ := mime.ParseMediaType(strings.TrimSpace(accepted))
+		if mediaType == "text/csv" {
+			return true
+		}
+		if _, ok := codecForMediaType(mediaType); ok {
+			return false
+		}
+	}
+	return false
+}
+
+// writeEvents writes a list of events as CSV if requested, otherwise in the
+// format negotiated from the Accept header
+func writeEvents(w http.ResponseWriter, r *http.Request, events []*model.Event) {
+	if !wantsCSV(r) {
+		writeResponse(w, r, http.StatusOK, toEventResponses(events))
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	// csv.Writer quotes fields with commas, quotes or newlines and flushes
+	// to the response as its buffer fills
+	out := csv.NewWriter(w)
+	out.Write(csvColumns)
+	for _, event := range events {
+		processedAt := ""
+		if event.ProcessedAt != nil {
+			processedAt = event.ProcessedAt.Format(time.RFC3339Nano)
+		}
+		out.Write([]string{
+			event.EventID,
+			string(event.Status),
+			event.CreatedAt.Format(time.RFC3339Nano),
+			processedAt,
+			string(event.Payload),
+		})
+	}
+	out.Flush()
+}
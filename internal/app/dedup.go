@@ -0,0 +1,75 @@
+package app
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"event-service/internal/model"
+	"strings"
+)
+
+// Duplicate policies for a resubmitted event_id
+const (
+	// duplicateReject rejects every duplicate with 409
+	duplicateReject = "reject"
+	// duplicateCompare accepts a resubmission with the same payload as an
+	// idempotent replay and rejects one with a different payload
+	duplicateCompare = "compare"
+)
+
+// dedupKey builds the producer-defined dedup key from the values at the
+// given dot-separated payload paths, e.g. "type" and "order.external_id".
+// It returns false if the payload lacks any of them, in which case the
+// event is only deduplicated by event_id.
+func dedupKey(payload json.RawMessage, paths []string) (string, bool) {
+	if len(paths) == 0 || len(payload) == 0 {
+		return "", false
+	}
+	var root interface{}
+	if err := model.UnmarshalPayload(payload, &root); err != nil {
+		return "", false
+	}
+
+	values := make([]interface{}, len(paths))
+	for i, path := range paths {
+		value, ok := lookupPath(root, path)
+		if !ok {
+			return "", false
+		}
+		values[i] = value
+	}
+	// Re-encoding the decoded values makes the key insensitive to formatting
+	key, err := json.Marshal(values)
+	if err != nil {
+		return "", false
+	}
+	return string(key), true
+}
+
+// payloadHash hashes the payload's canonical form, so payloads that differ
+// only in key order or whitespace hash the same
+func payloadHash(payload json.RawMessage) string {
+	canonical := []byte(payload)
+	var value interface{}
+	if model.UnmarshalPayload(payload, &value) == nil {
+		if encoded, err := json.Marshal(value); err == nil {
+			canonical = encoded
+		}
+	}
+	sum := sha256.Sum256(canonical)
+	return hex.EncodeToString(sum[:])
+}
+
+// lookupPath follows a dot-separated path through nested objects
+func lookupPath(value interface{}, path string) (interface{}, bool) {
+	for _, field := range strings.Split(path, ".") {
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if value, ok = object[field]; !ok {
+			return nil, false
+		}
+	}
+	return value, true
+}
@@ -0,0 +1,117 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDedupKey(t *testing.T) {
+	paths := []string{"type", "order.external_id"}
+
+	a, ok := dedupKey(json.RawMessage(`{"type": "order", "order": {"external_id": 42}}`), paths)
+	if !ok {
+		t.Fatal("Expected a dedup key")
+	}
+	b, _ := dedupKey(json.RawMessage(`{"order":{"external_id":42,"note":"x"},"type":"order"}`), paths)
+	if a != b {
+		t.Errorf("Expected formatting-independent keys, got %s and %s", a, b)
+	}
+
+	if _, ok := dedupKey(json.RawMessage(`{"type": "order"}`), paths); ok {
+		t.Error("Expected no key when a path is missing")
+	}
+	if _, ok := dedupKey(json.RawMessage(`{"type": "order"}`), nil); ok {
+		t.Error("Expected no key without configured paths")
+	}
+}
+
+func TestCompositeDedup(t *testing.T) {
+	application := newTestApp(t, Config{AllowGeneratedIDs: true, DedupKeyPaths: []string{"type", "external_id"}})
+
+	submit := func(body string) int {
+		rec := httptest.NewRecorder()
+		application.handleEvents(rec, httptest.NewRequest(http.MethodPost, "/events", strings.NewReader(body)))
+		return rec.Code
+	}
+
+	if code := submit(`{"payload": {"type": "order", "external_id": "A1"}}`); code != http.StatusAccepted {
+		t.Fatalf("Expected 202, got %d", code)
+	}
+	if code := submit(`{"payload": {"type": "order", "external_id": "A1", "retry": true}}`); code != http.StatusConflict {
+		t.Errorf("Expected 409 for the same composite key, got %d", code)
+	}
+	if code := submit(`{"payload": {"type": "order", "external_id": "A2"}}`); code != http.StatusAccepted {
+		t.Errorf("Expected 202 for a different composite key, got %d", code)
+	}
+}
+
+func TestPayloadHash(t *testing.T) {
+	if payloadHash(json.RawMessage(`{"a": 1, "b": [1, 2]}`)) != payloadHash(json.RawMessage(`{"b":[1,2],"a":1}`)) {
+		t.Error("Expected formatting-independent hashes")
+	}
+	if payloadHash(json.RawMessage(`{"a": 1}`)) == payloadHash(json.RawMessage(`{"a": 2}`)) {
+		t.Error("Expected different payloads to hash differently")
+	}
+}
+
+func TestDuplicatePolicyCompare(t *testing.T) {
+	application := newTestApp(t, Config{DuplicatePolicy: duplicateCompare})
+
+	submit := func(body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		application.handleEvents(rec, httptest.NewRequest(http.MethodPost, "/events", strings.NewReader(body)))
+		return rec
+	}
+
+	if rec := submit(`{"event_id": "evt_1", "payload": {"a": 1, "b": 2}}`); rec.Code != http.StatusAccepted {
+		t.Fatalf("Expected 202, got %d", rec.Code)
+	}
+	if rec := submit(`{"event_id": "evt_1", "payload": {"b": 2, "a": 1}}`); rec.Code != http.StatusAccepted {
+		t.Errorf("Expected 202 for a replay with the same payload, got %d", rec.Code)
+	}
+
+	rec := submit(`{"event_id": "evt_1", "payload": {"a": 2}}`)
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("Expected 409 for a reused ID, got %d", rec.Code)
+	}
+	var body struct{ Code string }
+	json.NewDecoder(rec.Body).Decode(&body)
+	if body.Code != string(errEventIDReused) {
+		t.Errorf("Expected code %s, got %q", errEventIDReused, body.Code)
+	}
+
+	if events := application.store.List(); len(events) != 1 {
+		t.Errorf("Expected a single stored event, got %d", len(events))
+	}
+}
+
+func TestExpiredEventIDCanBeReused(t *testing.T) {
+	application := newTestApp(t, Config{WorkerMode: "manual", IdempotencyTTLMs: 100})
+	application.worker.Start()
+	defer application.worker.Stop(context.Background())
+	defer application.store.Close()
+
+	post := func() int {
+		rec := httptest.NewRecorder()
+		body := strings.NewReader(`{"event_id": "evt_recycled", "payload": {}}`)
+		application.handleEvents(rec, httptest.NewRequest(http.MethodPost, "/events", body))
+		return rec.Code
+	}
+	if code := post(); code != http.StatusAccepted {
+		t.Fatalf("Expected 202, got %d", code)
+	}
+	application.worker.Tick(1)
+	if code := post(); code != http.StatusConflict {
+		t.Errorf("Expected 409 within the TTL, got %d", code)
+	}
+
+	time.Sleep(150 * time.Millisecond)
+	if code := post(); code != http.StatusAccepted {
+		t.Errorf("Expected the expired ID to be accepted again, got %d", code)
+	}
+}
@@ -0,0 +1,214 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"event-service/internal/model"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// errorCode identifies an API error independently of the language of its
+// message, so clients can handle errors programmatically
+type errorCode string
+
+const (
+	errMethodNotAllowed       errorCode = "method_not_allowed"
+	errShuttingDown           errorCode = "shutting_down"
+	errInvalidRequestBody     errorCode = "invalid_request_body"
+	errEventIDGeneration      errorCode = "event_id_generation_failed"
+	errEventIDRequired        errorCode = "event_id_required"
+	errEventIDWhitespace      errorCode = "event_id_whitespace"
+	errEventIDTooLong         errorCode = "event_id_too_long"
+	errEventIDReserved        errorCode = "event_id_reserved"
+	errInvalidPayload         errorCode = "invalid_payload"
+	errIdempotencyUnavailable errorCode = "idempotency_unavailable"
+	errEventNotFound          errorCode = "event_not_found"
+	errInvalidModifiedAfter   errorCode = "invalid_modified_after"
+	errCursorExpired          errorCode = "cursor_expired"
+	errNotManualMode          errorCode = "worker_not_manual"
+	errInvalidTickCount       errorCode = "invalid_tick_count"
+	errTypeRequired           errorCode = "type_required"
+	errInflightBytesExceeded  errorCode = "inflight_bytes_exceeded"
+	errScheduleConflict       errorCode = "schedule_conflict"
+	errNegativeDelay          errorCode = "negative_delay"
+	errNegativePriority       errorCode = "negative_priority"
+	errInvalidLimit           errorCode = "invalid_limit"
+	errInvalidOffset          errorCode = "invalid_offset"
+	errBodyReadTimeout        errorCode = "body_read_timeout"
+	errPayloadTooLarge        errorCode = "payload_too_large"
+	errEventIDReused          errorCode = "event_id_reused"
+	errInvalidStatus          errorCode = "invalid_status"
+	errStoreUnavailable       errorCode = "store_unavailable"
+	errFieldNotIndexed        errorCode = "field_not_indexed"
+	errInvalidThreshold       errorCode = "invalid_threshold"
+	errBatchTooLarge          errorCode = "batch_too_large"
+	errQueueFull              errorCode = "queue_full"
+	errEventFinished          errorCode = "event_finished"
+	errUnauthorized           errorCode = "unauthorized"
+	errOriginNotAllowed       errorCode = "origin_not_allowed"
+	errRateLimited            errorCode = "rate_limited"
+	errInvalidCallbackURL     errorCode = "invalid_callback_url"
+	errInvalidFormat          errorCode = "invalid_format"
+)
+
+// defaultMessages is the English catalog. Messages are fmt templates; a
+// translation must use the same verbs in the same order.
+var defaultMessages = map[errorCode]string{
+	errMethodNotAllowed:       "Method not allowed",
+	errShuttingDown:           "Service is shutting down",
+	errInvalidRequestBody:     "Invalid request body",
+	errEventIDGeneration:      "Failed to generate event_id",
+	errEventIDRequired:        "event_id is required",
+	errEventIDWhitespace:      "event_id must not have leading or trailing whitespace",
+	errEventIDTooLong:         "event_id must be at most %d bytes",
+	errEventIDReserved:        "event_id %s is reserved for an API route",
+	errInvalidPayload:         "Invalid payload: %s",
+	errIdempotencyUnavailable: "Idempotency check unavailable",
+	errEventNotFound:          "Event not found",
+	errInvalidModifiedAfter:   "modified_after must be 0 or a cursor from a previous sync",
+	errCursorExpired:          "Cursor is from another instance or before a restart, sync again from modified_after=0",
+	errNotManualMode:          "Worker is not in manual mode",
+	errInvalidTickCount:       "n must be a positive integer",
+	errTypeRequired:           "type is required",
+	errInflightBytesExceeded:  "Too many payload bytes in flight, retry later",
+	errScheduleConflict:       "process_at and delay_ms are mutually exclusive",
+	errNegativeDelay:          "delay_ms must not be negative",
+	errNegativePriority:       "priority must not be negative",
+	errInvalidLimit:           "limit must be a positive integer",
+	errInvalidOffset:          "offset must be a non-negative integer",
+	errBodyReadTimeout:        "Request body was not received in time",
+	errPayloadTooLarge:        "Request body must be at most %d bytes",
+	errEventIDReused:          "event_id was already used with a different payload",
+	errInvalidStatus:          "status must be accepted, scheduled, processed or failed",
+	errStoreUnavailable:       "Event could not be stored, retry later",
+	errFieldNotIndexed:        "%s is not an indexed payload field",
+	errInvalidThreshold:       "threshold_ms must be a positive integer",
+	errBatchTooLarge:          "A batch must have at most %d events",
+	errQueueFull:              "Processing queue is full, retry later",
+	errEventFinished:          "Payload can't be changed once an event is %s",
+	errUnauthorized:           "A valid API key is required",
+	errOriginNotAllowed:       "Origin is not allowed",
+	errRateLimited:            "Too many requests from this client, retry later",
+	errInvalidCallbackURL:     "callback_url must be an absolute http or https URL",
+	errInvalidFormat:          "format must be json or csv",
+}
+
+// apiError is an error with a stable code and the arguments for its message
+type apiError struct {
+	code errorCode
+	args []interface{}
+}
+
+func newAPIError(code errorCode, args ...interface{}) *apiError {
+	return &apiError{code: code, args: args}
+}
+
+// Error returns the English message
+func (e *apiError) Error() string {
+	return fmt.Sprintf(defaultMessages[e.code], e.args...)
+}
+
+// messageCatalog maps a lowercase language tag (e.g. "de" or "pt-br") to
+// translated messages. Codes missing from a locale fall back to English.
+type messageCatalog struct {
+	locales       map[string]map[errorCode]string
+	defaultLocale string
+}
+
+// loadMessageCatalog reads translations from a JSON file of the form
+// {"de": {"event_not_found": "Ereignis nicht gefunden"}}. An empty path
+// yields an English-only catalog.
+func loadMessageCatalog(path, defaultLocale string) (*messageCatalog, error) {
+	catalog := &messageCatalog{
+		locales:       make(map[string]map[errorCode]string),
+		defaultLocale: strings.ToLower(defaultLocale),
+	}
+	if path == "" {
+		return catalog, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return catalog, err
+	}
+	var locales map[string]map[errorCode]string
+	if err := json.Unmarshal(data, &locales); err != nil {
+		return catalog, fmt.Errorf("parse %s: %w", path, err)
+	}
+	for locale, messages := range locales {
+		catalog.locales[strings.ToLower(locale)] = messages
+	}
+	return catalog, nil
+}
+
+// message returns the message for the error in the best language from an
+// Accept-Language header, along with the locale that was used
+func (c *messageCatalog) message(acceptLanguage string, e *apiError) (string, string) {
+	for _, locale := range append(preferredLanguages(acceptLanguage), c.defaultLocale) {
+		if template, ok := c.locales[locale][e.code]; ok {
+			return fmt.Sprintf(template, e.args...), locale
+		}
+		// A regional tag like de-CH can use the generic de messages
+		if base, _, found := strings.Cut(locale, "-"); found {
+			if template, ok := c.locales[base][e.code]; ok {
+				return fmt.Sprintf(template, e.args...), base
+			}
+		}
+	}
+	return e.Error(), "en"
+}
+
+// preferredLanguages returns the lowercase language tags of an
+// Accept-Language header, highest quality first, omitting q=0 and *
+func preferredLanguages(header string) []string {
+	type weighted struct {
+		tag     string
+		quality float64
+	}
+	var tags []weighted
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || tag == "*" {
+			continue
+		}
+		quality := 1.0
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if parsed, err := strconv.ParseFloat(q, 64); err == nil {
+				quality = parsed
+			}
+		}
+		if quality > 0 {
+			tags = append(tags, weighted{tag, quality})
+		}
+	}
+	sort.SliceStable(tags, func(i, j int) bool { return tags[i].quality > tags[j].quality })
+
+	languages := make([]string, len(tags))
+	for i, t := range tags {
+		languages[i] = t.tag
+	}
+	return languages
+}
+
+// writeError responds with a JSON error body localized for the client
+func (a *App) writeError(w http.ResponseWriter, r *http.Request, status int, err *apiError) {
+	message, locale := a.messages.message(r.Header.Get("Accept-Language"), err)
+	w.Header().Set("Content-Language", locale)
+	writeJSON(w, status, model.ErrorResponse{Code: string(err.code), Message: message})
+}
+
+// asAPIError returns err as an *apiError, wrapping errors without a code as
+// an invalid request body
+func asAPIError(err error) *apiError {
+	var apiErr *apiError
+	if errors.As(err, &apiErr) {
+		return apiErr
+	}
+	return newAPIError(errInvalidRequestBody)
+}
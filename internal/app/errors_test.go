@@ -0,0 +1,59 @@
+package app
+
+import (
+	"encoding/json"
+	"event-service/internal/model"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPreferredLanguages(t *testing.T) {
+	got := preferredLanguages("fr;q=0.5, de-CH, en;q=0.8, *;q=0.1, es;q=0")
+	want := []string{"de-ch", "en", "fr"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
+
+func TestLocalizedErrors(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "messages.json")
+	os.WriteFile(path, []byte(`{"de": {"event_id_too_long": "event_id darf höchstens %d Bytes lang sein"}}`), 0o600)
+	application := newTestApp(t, Config{MaxEventIDLength: 4, ErrorMessagesFile: path, DefaultLocale: "en"})
+
+	tests := []struct {
+		acceptLanguage string
+		message        string
+		locale         string
+	}{
+		{"de-CH, en;q=0.5", "event_id darf höchstens 4 Bytes lang sein", "de"},
+		{"fr", "event_id must be at most 4 bytes", "en"},
+		{"", "event_id must be at most 4 bytes", "en"},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodPost, "/events", strings.NewReader(`{"event_id": "too_long"}`))
+		req.Header.Set("Accept-Language", tt.acceptLanguage)
+		rec := httptest.NewRecorder()
+		application.handleEvents(rec, req)
+		if rec.Code != http.StatusBadRequest {
+			t.Fatalf("Expected 400, got %d", rec.Code)
+		}
+
+		var resp model.ErrorResponse
+		json.NewDecoder(rec.Body).Decode(&resp)
+		if resp.Code != string(errEventIDTooLong) {
+			t.Errorf("Expected code %s, got %q", errEventIDTooLong, resp.Code)
+		}
+		if resp.Message != tt.message {
+			t.Errorf("%q: expected message %q, got %q", tt.acceptLanguage, tt.message, resp.Message)
+		}
+		if got := rec.Header().Get("Content-Language"); got != tt.locale {
+			t.Errorf("%q: expected Content-Language %s, got %s", tt.acceptLanguage, tt.locale, got)
+		}
+	}
+}
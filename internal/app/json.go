@@ -0,0 +1,22 @@
+package app
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// writeJSON encodes v as the JSON response body with the given status
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// emptyIfNil returns a non-nil slice so that empty results encode as []
+// rather than null, which strict JSON clients reject
+func emptyIfNil[T any](items []T) []T {
+	if items == nil {
+		return []T{}
+	}
+	return items
+}
@@ -0,0 +1,80 @@
+package app
+
+import (
+	"context"
+	"event-service/internal/model"
+	"event-service/internal/worker"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// metrics holds the Prometheus collectors served on /metrics. They live in
+// their own registry so several App instances (as in tests) don't collide.
+type metrics struct {
+	registry           *prometheus.Registry
+	processingDuration prometheus.Histogram
+}
+
+// newMetrics registers the service's collectors. Counters and the queue
+// gauge read the lifetime stats the app and worker already keep, so only
+// the processing duration needs its own instrumentation.
+func newMetrics(a *App) *metrics {
+	m := &metrics{
+		registry: prometheus.NewRegistry(),
+		processingDuration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name:    "processing_duration_seconds",
+			Help:    "Time spent processing an event, including failed attempts.",
+			Buckets: prometheus.DefBuckets,
+		}),
+	}
+	m.registry.MustRegister(
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Name: "events_received_total",
+			Help: "Events accepted by POST /events.",
+		}, func() float64 { return float64(a.accepted.Load()) }),
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Name: "events_processed_total",
+			Help: "Events processed successfully.",
+		}, func() float64 { return float64(a.worker.Stats().Processed) }),
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Name: "events_failed_total",
+			Help: "Events that failed for good, after their last retry.",
+		}, func() float64 { return float64(a.worker.Stats().Failed) }),
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Name: "events_failed_attempts_total",
+			Help: "Failed processing attempts, including those retried.",
+		}, func() float64 { return float64(a.worker.Stats().FailedAttempts) }),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name: "queue_depth",
+			Help: "Events waiting in the processing queue.",
+		}, func() float64 { return float64(a.worker.QueueDepth()) }),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name: "inflight_bytes",
+			Help: "Payload bytes of accepted events not yet processed.",
+		}, func() float64 { return float64(a.worker.InflightBytes()) }),
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Name: "processing_started_total",
+			Help: "Processing attempts started, after the PROCESS_RATE_PER_SEC throttle; its rate is the effective processing rate.",
+		}, func() float64 { return float64(a.worker.Stats().Started) }),
+		m.processingDuration,
+	)
+	return m
+}
+
+// middleware observes the processing duration of every attempt
+func (m *metrics) middleware(next worker.ProcessFunc) worker.ProcessFunc {
+	return func(ctx context.Context, event *model.Event) error {
+		start := time.Now()
+		err := next(ctx, event)
+		m.processingDuration.Observe(time.Since(start).Seconds())
+		return err
+	}
+}
+
+// handler serves the registry in the Prometheus text format
+func (m *metrics) handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
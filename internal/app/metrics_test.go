@@ -0,0 +1,122 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"event-service/internal/model"
+	"event-service/internal/worker"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMetricsEndpoint(t *testing.T) {
+	application := newTestApp(t, Config{MetricsEnabled: true, WorkerMode: "manual"})
+	application.worker.Start()
+	defer application.worker.Stop(context.Background())
+
+	rec := httptest.NewRecorder()
+	body := strings.NewReader(`{"event_id": "evt_1", "payload": {}}`)
+	application.handleEvents(rec, httptest.NewRequest(http.MethodPost, "/events", body))
+	application.worker.Tick(1)
+
+	rec = httptest.NewRecorder()
+	application.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", rec.Code)
+	}
+	for _, expected := range []string{
+		"events_received_total 1",
+		"events_processed_total 1",
+		"events_failed_total 0",
+		"events_failed_attempts_total 0",
+		"queue_depth 0",
+		"processing_duration_seconds_count 1",
+	} {
+		if !strings.Contains(rec.Body.String(), expected) {
+			t.Errorf("Expected %q in metrics output", expected)
+		}
+	}
+}
+
+func TestMetricsFailedEventsAndAttempts(t *testing.T) {
+	application := newTestApp(t, Config{MetricsEnabled: true, WorkerMode: "manual", MaxRetries: 1, RetryBackoffMs: 60000})
+	application.worker.Use(func(next worker.ProcessFunc) worker.ProcessFunc {
+		return func(ctx context.Context, event *model.Event) error {
+			return errors.New("rejected")
+		}
+	})
+	application.worker.Start()
+	defer application.worker.Stop(context.Background())
+
+	rec := httptest.NewRecorder()
+	body := strings.NewReader(`{"event_id": "evt_1", "payload": {}}`)
+	application.handleEvents(rec, httptest.NewRequest(http.MethodPost, "/events", body))
+	application.worker.Tick(1)
+
+	// The failed attempt is retried later, so the event hasn't failed yet
+	rec = httptest.NewRecorder()
+	application.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	for _, expected := range []string{"events_failed_total 0\n", "events_failed_attempts_total 1\n"} {
+		if !strings.Contains(rec.Body.String(), expected) {
+			t.Errorf("Expected %q in metrics output", expected)
+		}
+	}
+}
+
+func TestMetricsInflightBytes(t *testing.T) {
+	application := newTestApp(t, Config{MetricsEnabled: true, WorkerMode: "manual"})
+	application.worker.Start()
+	defer application.worker.Stop(context.Background())
+
+	scrape := func() string {
+		rec := httptest.NewRecorder()
+		application.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+		return rec.Body.String()
+	}
+
+	rec := httptest.NewRecorder()
+	body := strings.NewReader(`{"event_id": "evt_1", "payload": {"n": 123456}}`)
+	application.handleEvents(rec, httptest.NewRequest(http.MethodPost, "/events", body))
+	if !strings.Contains(scrape(), "inflight_bytes 13\n") {
+		t.Error("Expected the queued payload to count as in flight")
+	}
+	application.worker.Tick(1)
+	if !strings.Contains(scrape(), "inflight_bytes 0\n") {
+		t.Error("Expected nothing in flight once the event is processed")
+	}
+}
+
+func TestMetricsProcessingStarted(t *testing.T) {
+	application := newTestApp(t, Config{MetricsEnabled: true, WorkerMode: "manual", ProcessRatePerSec: 50})
+	application.worker.Start()
+	defer application.worker.Stop(context.Background())
+
+	for _, id := range []string{"a", "b", "c"} {
+		rec := httptest.NewRecorder()
+		body := strings.NewReader(`{"event_id": "` + id + `", "payload": {}}`)
+		application.handleEvents(rec, httptest.NewRequest(http.MethodPost, "/events", body))
+	}
+	start := time.Now()
+	application.worker.Tick(3)
+	if elapsed := time.Since(start); elapsed < 40*time.Millisecond {
+		t.Errorf("Expected 3 events at 50/s to take at least 40ms, took %v", elapsed)
+	}
+
+	rec := httptest.NewRecorder()
+	application.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if !strings.Contains(rec.Body.String(), "processing_started_total 3\n") {
+		t.Errorf("Expected 3 started attempts in metrics output")
+	}
+}
+
+func TestMetricsDisabledByDefault(t *testing.T) {
+	application := newTestApp(t, Config{})
+	rec := httptest.NewRecorder()
+	application.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 without METRICS_ENABLED, got %d", rec.Code)
+	}
+}
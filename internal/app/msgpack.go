@@ -0,0 +1,262 @@
+package app
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+)
+
+// maxMsgpackDepth bounds nesting so hostile input can't exhaust the stack
+const maxMsgpackDepth = 1000
+
+var errMsgpackTruncated = errors.New("msgpack: unexpected end of data")
+
+// marshalMsgpack appends the MessagePack encoding of a generic JSON value
+// (nil, bool, json.Number, float64, string, []interface{} or
+// map[string]interface{}) to buf. Map keys are written in sorted order.
+func marshalMsgpack(buf []byte, value interface{}) []byte {
+	switch v := value.(type) {
+	case nil:
+		return append(buf, 0xc0)
+	case bool:
+		if v {
+			return append(buf, 0xc3)
+		}
+		return append(buf, 0xc2)
+	case json.Number:
+		if i, err := strconv.ParseInt(v.String(), 10, 64); err == nil {
+			return appendMsgpackInt(buf, i)
+		}
+		f, _ := v.Float64()
+		return appendMsgpackFloat(buf, f)
+	case float64:
+		return appendMsgpackFloat(buf, v)
+	case string:
+		buf = appendMsgpackHeader(buf, len(v), 0xa0, 32, 0xd9, 0xda, 0xdb)
+		return append(buf, v...)
+	case []interface{}:
+		buf = appendMsgpackHeader(buf, len(v), 0x90, 16, 0, 0xdc, 0xdd)
+		for _, item := range v {
+			buf = marshalMsgpack(buf, item)
+		}
+		return buf
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		buf = appendMsgpackHeader(buf, len(v), 0x80, 16, 0, 0xde, 0xdf)
+		for _, key := range keys {
+			buf = marshalMsgpack(buf, key)
+			buf = marshalMsgpack(buf, v[key])
+		}
+		return buf
+	}
+	panic(fmt.Sprintf("msgpack: unsupported type %T", value))
+}
+
+// appendMsgpackHeader writes a length header using the fix form below
+// fixLimit, or the 8, 16 or 32 bit form (code8 of 0 means no 8 bit form)
+func appendMsgpackHeader(buf []byte, n int, fix byte, fixLimit int, code8, code16, code32 byte) []byte {
+	switch {
+	case n < fixLimit:
+		return append(buf, fix|byte(n))
+	case code8 != 0 && n <= math.MaxUint8:
+		return append(buf, code8, byte(n))
+	case n <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(buf, code16), uint16(n))
+	default:
+		return binary.BigEndian.AppendUint32(append(buf, code32), uint32(n))
+	}
+}
+
+func appendMsgpackInt(buf []byte, i int64) []byte {
+	switch {
+	case i >= 0 && i <= 0x7f:
+		return append(buf, byte(i))
+	case i < 0 && i >= -32:
+		return append(buf, byte(i))
+	default:
+		return binary.BigEndian.AppendUint64(append(buf, 0xd3), uint64(i))
+	}
+}
+
+func appendMsgpackFloat(buf []byte, f float64) []byte {
+	return binary.BigEndian.AppendUint64(append(buf, 0xcb), math.Float64bits(f))
+}
+
+// unmarshalMsgpack decodes a single MessagePack value into generic Go values
+// that encoding/json can marshal. Binary data becomes a string; extension
+// types and non-string map keys are rejected.
+func unmarshalMsgpack(data []byte) (interface{}, error) {
+	d := &msgpackDecoder{data: data}
+	value, err := d.value(0)
+	if err != nil {
+		return nil, err
+	}
+	if d.pos != len(d.data) {
+		return nil, errors.New("msgpack: unexpected data after top-level value")
+	}
+	return value, nil
+}
+
+type msgpackDecoder struct {
+	data []byte
+	pos  int
+}
+
+func (d *msgpackDecoder) value(depth int) (interface{}, error) {
+	if depth > maxMsgpackDepth {
+		return nil, errors.New("msgpack: nesting too deep")
+	}
+	code, err := d.byte()
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case code <= 0x7f:
+		return int64(code), nil
+	case code >= 0xe0:
+		return int64(int8(code)), nil
+	case code&0xf0 == 0x80:
+		return d.mapValue(int(code&0x0f), depth)
+	case code&0xf0 == 0x90:
+		return d.arrayValue(int(code&0x0f), depth)
+	case code&0xe0 == 0xa0:
+		return d.str(int(code & 0x1f))
+	}
+
+	switch code {
+	case 0xc0:
+		return nil, nil
+	case 0xc2:
+		return false, nil
+	case 0xc3:
+		return true, nil
+	case 0xc4, 0xd9:
+		return d.sized(1, d.str)
+	case 0xc5, 0xda:
+		return d.sized(2, d.str)
+	case 0xc6, 0xdb:
+		return d.sized(4, d.str)
+	case 0xca:
+		b, err := d.bytes(4)
+		if err != nil {
+			return nil, err
+		}
+		return float64(math.Float32frombits(binary.BigEndian.Uint32(b))), nil
+	case 0xcb:
+		b, err := d.bytes(8)
+		if err != nil {
+			return nil, err
+		}
+		return math.Float64frombits(binary.BigEndian.Uint64(b)), nil
+	case 0xcc, 0xcd, 0xce, 0xcf:
+		n, err := d.uint(1 << (code - 0xcc))
+		return n, err
+	case 0xd0, 0xd1, 0xd2, 0xd3:
+		size := 1 << (code - 0xd0)
+		n, err := d.uint(size)
+		if err != nil {
+			return nil, err
+		}
+		// Sign-extend from the encoded width
+		shift := 64 - 8*size
+		return int64(n<<shift) >> shift, nil
+	case 0xdc:
+		return d.sized(2, func(n int) (interface{}, error) { return d.arrayValue(n, depth) })
+	case 0xdd:
+		return d.sized(4, func(n int) (interface{}, error) { return d.arrayValue(n, depth) })
+	case 0xde:
+		return d.sized(2, func(n int) (interface{}, error) { return d.mapValue(n, depth) })
+	case 0xdf:
+		return d.sized(4, func(n int) (interface{}, error) { return d.mapValue(n, depth) })
+	}
+	return nil, fmt.Errorf("msgpack: unsupported type code 0x%02x", code)
+}
+
+// sized reads a big-endian length of the given width and passes it on
+func (d *msgpackDecoder) sized(width int, next func(n int) (interface{}, error)) (interface{}, error) {
+	n, err := d.uint(width)
+	if err != nil {
+		return nil, err
+	}
+	// Every element takes at least one byte, so longer lengths are truncated
+	if n > uint64(len(d.data)-d.pos) {
+		return nil, errMsgpackTruncated
+	}
+	return next(int(n))
+}
+
+func (d *msgpackDecoder) arrayValue(n, depth int) (interface{}, error) {
+	items := make([]interface{}, 0, min(n, len(d.data)-d.pos))
+	for i := 0; i < n; i++ {
+		item, err := d.value(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+func (d *msgpackDecoder) mapValue(n, depth int) (interface{}, error) {
+	fields := make(map[string]interface{}, min(n, len(d.data)-d.pos))
+	for i := 0; i < n; i++ {
+		key, err := d.value(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		name, ok := key.(string)
+		if !ok {
+			return nil, fmt.Errorf("msgpack: map key must be a string, got %T", key)
+		}
+		if fields[name], err = d.value(depth + 1); err != nil {
+			return nil, err
+		}
+	}
+	return fields, nil
+}
+
+func (d *msgpackDecoder) str(n int) (interface{}, error) {
+	b, err := d.bytes(n)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+func (d *msgpackDecoder) uint(size int) (uint64, error) {
+	b, err := d.bytes(size)
+	if err != nil {
+		return 0, err
+	}
+	var n uint64
+	for _, c := range b {
+		n = n<<8 | uint64(c)
+	}
+	return n, nil
+}
+
+func (d *msgpackDecoder) byte() (byte, error) {
+	b, err := d.bytes(1)
+	if err != nil {
+		return 0, err
+	}
+	return b[0], nil
+}
+
+func (d *msgpackDecoder) bytes(n int) ([]byte, error) {
+	if n > len(d.data)-d.pos {
+		return nil, errMsgpackTruncated
+	}
+	b := d.data[d.pos : d.pos+n]
+	d.pos += n
+	return b, nil
+}
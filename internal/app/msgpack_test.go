@@ -0,0 +1,62 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestMarshalMsgpack(t *testing.T) {
+	value := map[string]interface{}{
+		"a": json.Number("1"),
+		"b": []interface{}{true, nil, "x"},
+	}
+	want := []byte{0x82, 0xa1, 'a', 0x01, 0xa1, 'b', 0x93, 0xc3, 0xc0, 0xa1, 'x'}
+	if got := marshalMsgpack(nil, value); !bytes.Equal(got, want) {
+		t.Errorf("Expected % x, got % x", want, got)
+	}
+}
+
+func TestMsgpackRoundTrip(t *testing.T) {
+	long := string(bytes.Repeat([]byte("s"), 300))
+	value := map[string]interface{}{
+		"negative": json.Number("-200"),
+		"small":    json.Number("-5"),
+		"float":    json.Number("1.5"),
+		"long":     long,
+		"nested":   map[string]interface{}{"list": []interface{}{false, json.Number("70000")}},
+	}
+
+	got, err := unmarshalMsgpack(marshalMsgpack(nil, value))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	want := map[string]interface{}{
+		"negative": int64(-200),
+		"small":    int64(-5),
+		"float":    1.5,
+		"long":     long,
+		"nested":   map[string]interface{}{"list": []interface{}{false, int64(70000)}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
+
+func TestUnmarshalMsgpackRejectsBadInput(t *testing.T) {
+	tests := map[string][]byte{
+		"truncated string":  {0xa5, 'a', 'b'},
+		"oversized array":   {0xdd, 0xff, 0xff, 0xff, 0xff},
+		"non-string key":    {0x81, 0x01, 0x02},
+		"trailing data":     {0xc0, 0xc0},
+		"extension type":    {0xd4, 0x01, 0x00},
+		"empty input":       {},
+		"truncated integer": {0xcd, 0x01},
+	}
+	for name, data := range tests {
+		if _, err := unmarshalMsgpack(data); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package app
+
+import (
+	_ "embed"
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// openAPISpec is the hand-maintained OpenAPI 3 document describing every
+// route. Update it together with the handlers.
+//
+//go:embed openapi.json
+var openAPISpec []byte
+
+// renderOpenAPI adapts the spec to this instance: the server URL carries the
+// base path, authentication is only described with API_KEY set, and routes
+// the main listener doesn't serve are left out
+func renderOpenAPI(config Config) ([]byte, error) {
+	var spec map[string]interface{}
+	if err := json.Unmarshal(openAPISpec, &spec); err != nil {
+		return nil, err
+	}
+
+	serverURL := config.BasePath
+	if serverURL == "" {
+		serverURL = "/"
+	}
+	spec["servers"] = []map[string]string{{"url": serverURL}}
+
+	paths := spec["paths"].(map[string]interface{})
+	if !config.MetricsEnabled {
+		delete(paths, "/metrics")
+	}
+	if config.APIKey == "" {
+		// Without API_KEY every operation is open
+		delete(spec["components"].(map[string]interface{}), "securitySchemes")
+		for _, operations := range paths {
+			for _, operation := range operations.(map[string]interface{}) {
+				operation, ok := operation.(map[string]interface{})
+				if _, secured := operation["security"]; ok && secured {
+					delete(operation, "security")
+					delete(operation["responses"].(map[string]interface{}), "401")
+				}
+			}
+		}
+	}
+	if config.AdminPort != "" {
+		// Operational endpoints are on the admin listener, not under the base path
+		for path := range paths {
+			if strings.HasPrefix(path, "/admin/") || strings.HasPrefix(path, "/debug/") || path == "/metrics" {
+				delete(paths, path)
+			}
+		}
+	}
+	return json.Marshal(spec)
+}
+
+// handleOpenAPI handles GET /openapi.json
+func (a *App) handleOpenAPI(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		a.writeError(w, r, http.StatusMethodNotAllowed, newAPIError(errMethodNotAllowed))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(a.openAPI)
+}
@@ -0,0 +1,80 @@
+package app
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestOpenAPIDescribesServedRoutes(t *testing.T) {
+	application := newTestApp(t, Config{OpenAPIEnabled: true, MetricsEnabled: true, BasePath: "/svc"})
+	handler := application.routes()
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/svc/openapi.json", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", rec.Code)
+	}
+	var spec struct {
+		Servers []struct {
+			URL string `json:"url"`
+		} `json:"servers"`
+		Paths map[string]map[string]interface{} `json:"paths"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &spec); err != nil {
+		t.Fatalf("Expected a JSON spec, got %v", err)
+	}
+	if len(spec.Servers) != 1 || spec.Servers[0].URL != "/svc" {
+		t.Errorf("Expected the base path as server URL, got %+v", spec.Servers)
+	}
+
+	// Every documented operation must reach a handler rather than the mux's
+	// plain-text not found page
+	for path, operations := range spec.Paths {
+		for method := range operations {
+			target := "/svc" + strings.Replace(path, "{id}", "evt_1", 1)
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(strings.ToUpper(method), target, nil))
+			if strings.Contains(rec.Body.String(), "404 page not found") {
+				t.Errorf("%s %s is documented but not served", strings.ToUpper(method), path)
+			}
+		}
+	}
+}
+
+func TestOpenAPIOmitsDisabledRoutes(t *testing.T) {
+	spec, err := renderOpenAPI(Config{AdminPort: "9091"})
+	if err != nil {
+		t.Fatalf("Expected spec to render, got %v", err)
+	}
+	for _, path := range []string{`"/metrics"`, `"/admin/tick"`, `"/debug/paused"`} {
+		if strings.Contains(string(spec), path) {
+			t.Errorf("Expected %s to be left out", path)
+		}
+	}
+	if !strings.Contains(string(spec), `"/events"`) {
+		t.Error("Expected /events to be documented")
+	}
+}
+
+func TestOpenAPIDescribesAuthOnlyWithAPIKey(t *testing.T) {
+	open, err := renderOpenAPI(Config{})
+	if err != nil {
+		t.Fatalf("Expected spec to render, got %v", err)
+	}
+	if strings.Contains(string(open), `"security"`) || strings.Contains(string(open), `"401"`) {
+		t.Error("Expected no authentication without API_KEY")
+	}
+	secured, err := renderOpenAPI(Config{APIKey: "s3cret"})
+	if err != nil {
+		t.Fatalf("Expected spec to render, got %v", err)
+	}
+	if !strings.Contains(string(secured), `"securitySchemes"`) || !strings.Contains(string(secured), `"401"`) {
+		t.Error("Expected authentication to be described with API_KEY")
+	}
+	if strings.Contains(string(secured), "s3cret") {
+		t.Error("Expected the API key itself not to be published")
+	}
+}
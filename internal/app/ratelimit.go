@@ -0,0 +1,138 @@
+package app
+
+import (
+	"container/list"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// rateLimitMaxClients caps the buckets kept. Beyond it the least recently
+// used bucket is dropped, which lets that client start over with a full
+// burst; a bucket idle long enough to refill completely is dropped anyway.
+const rateLimitMaxClients = 100000
+
+// rateLimiter keeps a token bucket per client. Each bucket holds up to burst
+// tokens and refills at rate tokens per second; a request takes one.
+type rateLimiter struct {
+	mu      sync.Mutex
+	rate    float64
+	burst   float64
+	buckets map[string]*list.Element
+	// recent orders the buckets from most to least recently used
+	recent *list.List // of *tokenBucket
+}
+
+type tokenBucket struct {
+	client string
+	tokens float64
+	last   time.Time
+}
+
+// newRateLimiter creates a limiter allowing ratePerSec requests per second
+// per client in bursts of up to burst. A burst below 1 defaults to the rate
+// rounded up.
+func newRateLimiter(ratePerSec float64, burst int) *rateLimiter {
+	if burst < 1 {
+		burst = int(math.Ceil(ratePerSec))
+	}
+	return &rateLimiter{
+		rate:    ratePerSec,
+		burst:   float64(burst),
+		buckets: make(map[string]*list.Element),
+		recent:  list.New(),
+	}
+}
+
+// allow takes a token from the client's bucket. If it is empty, it returns
+// false and how long until the next token.
+func (l *rateLimiter) allow(client string, now time.Time) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.sweep(now)
+
+	var bucket *tokenBucket
+	if element, ok := l.buckets[client]; ok {
+		bucket = element.Value.(*tokenBucket)
+		l.recent.MoveToFront(element)
+	} else {
+		if len(l.buckets) >= rateLimitMaxClients {
+			l.drop(l.recent.Back())
+		}
+		bucket = &tokenBucket{client: client, tokens: l.burst, last: now}
+		l.buckets[client] = l.recent.PushFront(bucket)
+	}
+	bucket.tokens = math.Min(l.burst, bucket.tokens+now.Sub(bucket.last).Seconds()*l.rate)
+	bucket.last = now
+	if bucket.tokens < 1 {
+		return false, time.Duration((1 - bucket.tokens) / l.rate * float64(time.Second))
+	}
+	bucket.tokens--
+	return true, 0
+}
+
+// sweep drops the least recently used buckets that have refilled
+// completely, which behave the same as a new bucket. It stops at the first
+// bucket still refilling, so each call only pays for the buckets it drops.
+// Caller must hold l.mu.
+func (l *rateLimiter) sweep(now time.Time) {
+	refill := time.Duration(l.burst / l.rate * float64(time.Second))
+	for element := l.recent.Back(); element != nil; element = l.recent.Back() {
+		if now.Sub(element.Value.(*tokenBucket).last) < refill {
+			return
+		}
+		l.drop(element)
+	}
+}
+
+// drop removes a bucket. Caller must hold l.mu.
+func (l *rateLimiter) drop(element *list.Element) {
+	delete(l.buckets, element.Value.(*tokenBucket).client)
+	l.recent.Remove(element)
+}
+
+// withRateLimit applies RATE_LIMIT_RPS per client to the requests to handler
+// that may change events; reads are not limited. Without RATE_LIMIT_RPS the
+// handler is returned unchanged.
+func (a *App) withRateLimit(handler http.HandlerFunc) http.HandlerFunc {
+	if a.limiter == nil {
+		return handler
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet || r.Method == http.MethodHead {
+			handler(w, r)
+			return
+		}
+		client := a.clientIP(r)
+		if ok, wait := a.limiter.allow(client, time.Now()); !ok {
+			logf(r, "Rate limiting %s", client)
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			a.writeError(w, r, http.StatusTooManyRequests, newAPIError(errRateLimited))
+			return
+		}
+		handler(w, r)
+	}
+}
+
+// clientIP identifies the client of a request by IP address. With
+// TRUST_PROXY it is the last X-Forwarded-For entry, the address the proxy in
+// front of the service saw; otherwise it is the connection's peer address.
+func (a *App) clientIP(r *http.Request) string {
+	if a.config.TrustProxy {
+		if forwarded := r.Header.Values("X-Forwarded-For"); len(forwarded) > 0 {
+			entries := strings.Split(forwarded[len(forwarded)-1], ",")
+			if ip := strings.TrimSpace(entries[len(entries)-1]); ip != "" {
+				return ip
+			}
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,124 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRateLimiter(t *testing.T) {
+	l := newRateLimiter(2, 3)
+	now := time.Now()
+	for i := 0; i < 3; i++ {
+		if ok, _ := l.allow("a", now); !ok {
+			t.Fatalf("Expected request %d within the burst to be allowed", i+1)
+		}
+	}
+	ok, wait := l.allow("a", now)
+	if ok || wait != 500*time.Millisecond {
+		t.Errorf("Expected a 500ms wait once the burst is used, got %v %s", ok, wait)
+	}
+	if ok, _ := l.allow("b", now); !ok {
+		t.Error("Expected other clients to have their own bucket")
+	}
+	if ok, _ := l.allow("a", now.Add(500*time.Millisecond)); !ok {
+		t.Error("Expected a token to be refilled after 500ms")
+	}
+
+	// Buckets idle long enough to be full again are swept
+	l.allow("c", now.Add(time.Minute))
+	if _, tracked := l.buckets["a"]; tracked || len(l.buckets) != 1 {
+		t.Errorf("Expected idle buckets to be swept, got %d buckets", len(l.buckets))
+	}
+}
+
+func TestRateLimiterCapsClients(t *testing.T) {
+	l := newRateLimiter(1, 1)
+	now := time.Now()
+	if ok, _ := l.allow("first", now); !ok {
+		t.Fatal("Expected the first request to be allowed")
+	}
+	if ok, _ := l.allow("busy", now); !ok {
+		t.Fatal("Expected the first request to be allowed")
+	}
+	for i := 0; i < rateLimitMaxClients; i++ {
+		l.allow(fmt.Sprintf("client-%d", i), now)
+		if i == rateLimitMaxClients/2 {
+			// Using a bucket keeps it from being the one dropped
+			l.allow("busy", now)
+		}
+	}
+
+	if len(l.buckets) != rateLimitMaxClients || l.recent.Len() != rateLimitMaxClients {
+		t.Fatalf("Expected %d buckets at the cap, got %d", rateLimitMaxClients, len(l.buckets))
+	}
+	if _, tracked := l.buckets["first"]; tracked {
+		t.Error("Expected the least recently used bucket to be dropped")
+	}
+	if ok, _ := l.allow("busy", now); ok {
+		t.Error("Expected a recently used bucket to be kept, still empty")
+	}
+}
+
+func TestRateLimitThrottlesWrites(t *testing.T) {
+	application := newTestApp(t, Config{WorkerMode: "manual", RateLimitRPS: 1, RateLimitBurst: 5})
+	application.worker.Start()
+	defer application.worker.Stop(context.Background())
+	handler := application.routes()
+
+	post := func(i int, remoteAddr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/events", strings.NewReader(fmt.Sprintf(`{"event_id": "evt_%s_%d"}`, remoteAddr, i)))
+		req.RemoteAddr = remoteAddr + ":1234"
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	accepted, throttled := 0, 0
+	for i := 0; i < 20; i++ {
+		rec := post(i, "10.0.0.1")
+		switch rec.Code {
+		case http.StatusAccepted:
+			accepted++
+		case http.StatusTooManyRequests:
+			throttled++
+			if rec.Header().Get("Retry-After") == "" {
+				t.Error("Expected Retry-After on a throttled request")
+			}
+		default:
+			t.Fatalf("Unexpected status %d", rec.Code)
+		}
+	}
+	if accepted < 5 || throttled == 0 {
+		t.Errorf("Expected the burst to pass and the rest to be throttled, got %d accepted, %d throttled", accepted, throttled)
+	}
+
+	// Other clients and reads are unaffected
+	if rec := post(0, "10.0.0.2"); rec.Code != http.StatusAccepted {
+		t.Errorf("Expected another client to be accepted, got %d", rec.Code)
+	}
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/events", nil)
+	req.RemoteAddr = "10.0.0.1:1234"
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Errorf("Expected reads not to be limited, got %d", rec.Code)
+	}
+}
+
+func TestClientIP(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/events", nil)
+	req.RemoteAddr = "10.0.0.1:1234"
+	req.Header.Set("X-Forwarded-For", "203.0.113.9, 198.51.100.7")
+
+	if ip := (&App{}).clientIP(req); ip != "10.0.0.1" {
+		t.Errorf("Expected the peer address without TRUST_PROXY, got %s", ip)
+	}
+	if ip := (&App{config: Config{TrustProxy: true}}).clientIP(req); ip != "198.51.100.7" {
+		t.Errorf("Expected the address the proxy appended, got %s", ip)
+	}
+}
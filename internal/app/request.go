@@ -0,0 +1,136 @@
+package app
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"event-service/internal/model"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// decodeEventRequest reads an event submission from the request body.
+// JSON is the default and MessagePack is chosen by Content-Type;
+// application/x-www-form-urlencoded bodies carry the same fields as form
+// values, with payload given as a JSON string.
+func decodeEventRequest(r *http.Request) (model.EventRequest, error) {
+	var req model.EventRequest
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != "application/x-www-form-urlencoded" {
+		err := requestCodec(r).decode(r.Body, &req)
+		return req, err
+	}
+
+	if err := r.ParseForm(); err != nil {
+		return req, err
+	}
+	req.EventID = r.PostForm.Get("event_id")
+	req.Type = r.PostForm.Get("type")
+	req.CorrelationID = r.PostForm.Get("correlation_id")
+	req.CausationID = r.PostForm.Get("causation_id")
+	req.CallbackURL = r.PostForm.Get("callback_url")
+	if processAt := r.PostForm.Get("process_at"); processAt != "" {
+		t, err := time.Parse(time.RFC3339, processAt)
+		if err != nil {
+			return req, fmt.Errorf("process_at form field: %w", err)
+		}
+		req.ProcessAt = &t
+	}
+	if delayMs := r.PostForm.Get("delay_ms"); delayMs != "" {
+		delay, err := strconv.ParseInt(delayMs, 10, 64)
+		if err != nil {
+			return req, fmt.Errorf("delay_ms form field: %w", err)
+		}
+		req.DelayMs = delay
+	}
+	if priority := r.PostForm.Get("priority"); priority != "" {
+		p, err := strconv.Atoi(priority)
+		if err != nil {
+			return req, fmt.Errorf("priority form field: %w", err)
+		}
+		req.Priority = p
+	}
+	if payload := r.PostForm.Get("payload"); payload != "" {
+		if !json.Valid([]byte(payload)) {
+			return req, errors.New("payload form field is not valid JSON")
+		}
+		req.Payload = json.RawMessage(payload)
+	}
+	return req, nil
+}
+
+// limitBodyReadTime bounds how long reading the request body may take, so a
+// client trickling its body can't tie up the handler, and returns a func
+// that lifts the deadline again. Reads past the deadline fail with an error
+// matching os.ErrDeadlineExceeded. Writers that don't support deadlines,
+// such as the one behind REQUEST_TIMEOUT_MS, are left unbounded; there
+// readBodyAhead applies the deadline before the handler runs.
+func limitBodyReadTime(w http.ResponseWriter, timeout time.Duration) func() {
+	rc := http.NewResponseController(w)
+	if err := rc.SetReadDeadline(time.Now().Add(timeout)); err != nil {
+		return func() {}
+	}
+	return func() { rc.SetReadDeadline(time.Time{}) }
+}
+
+// limitBody applies MAX_PAYLOAD_BYTES and BODY_READ_TIMEOUT_MS to reading
+// the request body and returns the func that lifts the read deadline once
+// the body is read. Handlers pass read errors to rejectBody.
+func (a *App) limitBody(w http.ResponseWriter, r *http.Request) func() {
+	liftDeadline := func() {}
+	if a.config.BodyReadTimeoutMs > 0 {
+		liftDeadline = limitBodyReadTime(w, time.Duration(a.config.BodyReadTimeoutMs)*time.Millisecond)
+	}
+	if a.config.MaxPayloadBytes > 0 {
+		r.Body = http.MaxBytesReader(w, r.Body, int64(a.config.MaxPayloadBytes))
+	}
+	return liftDeadline
+}
+
+// readBodyAhead reads the request body into memory under
+// BODY_READ_TIMEOUT_MS before a handler behind REQUEST_TIMEOUT_MS runs, as
+// that handler's writer can't set read deadlines. It has to happen outside
+// http.TimeoutHandler: a read that times out also cancels the request's
+// context, and TimeoutHandler would then answer 503 instead of 408. Reading
+// stops one byte past MAX_PAYLOAD_BYTES so the handler still rejects larger
+// bodies. It reports whether to run the handler; if not, the client has
+// been answered.
+func (a *App) readBodyAhead(w http.ResponseWriter, r *http.Request) bool {
+	if r.Body == nil || r.Body == http.NoBody {
+		return true
+	}
+	liftDeadline := limitBodyReadTime(w, time.Duration(a.config.BodyReadTimeoutMs)*time.Millisecond)
+	var reader io.Reader = r.Body
+	if a.config.MaxPayloadBytes > 0 {
+		reader = io.LimitReader(r.Body, int64(a.config.MaxPayloadBytes)+1)
+	}
+	body, err := io.ReadAll(reader)
+	liftDeadline()
+	if a.rejectBody(w, r, err) {
+		return false
+	}
+	r.Body = readCloser{io.MultiReader(bytes.NewReader(body), r.Body), r.Body}
+	return true
+}
+
+// readCloser combines a reader with the closer of the body it reads from
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// newEventID returns a random (version 4) UUID for events submitted without an ID
+func newEventID() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", err
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:]), nil
+}
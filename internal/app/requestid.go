@@ -0,0 +1,60 @@
+package app
+
+import (
+	"context"
+	"log"
+	"net/http"
+)
+
+// maxRequestIDLength bounds a client-supplied X-Request-ID
+const maxRequestIDLength = 128
+
+type requestIDKey struct{}
+
+// withRequestID gives every request an ID, taken from its X-Request-ID
+// header or generated as a UUID, and echoes it in the response's
+// X-Request-ID header. Handlers read it with requestID and log with logf.
+func withRequestID(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get("X-Request-ID")
+		if !validRequestID(id) {
+			var err error
+			if id, err = newEventID(); err != nil {
+				log.Printf("Failed to generate request ID: %v", err)
+				handler.ServeHTTP(w, r)
+				return
+			}
+		}
+		w.Header().Set("X-Request-ID", id)
+		handler.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), requestIDKey{}, id)))
+	})
+}
+
+// validRequestID accepts IDs of printable ASCII without spaces, so a client
+// can't break up or forge log lines through the header
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// requestID returns the ID withRequestID gave the request, or "" if none
+func requestID(r *http.Request) string {
+	id, _ := r.Context().Value(requestIDKey{}).(string)
+	return id
+}
+
+// logf logs a line about the request, prefixed with its request ID
+func logf(r *http.Request, format string, args ...interface{}) {
+	if id := requestID(r); id != "" {
+		args = append([]interface{}{id}, args...)
+		format = "[%s] " + format
+	}
+	log.Printf(format, args...)
+}
@@ -0,0 +1,49 @@
+package app
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+
+func TestRequestID(t *testing.T) {
+	application := newTestApp(t, Config{WorkerMode: "manual"})
+	handler := application.routes()
+
+	submit := func(eventID, requestID string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/events", strings.NewReader(`{"event_id": "`+eventID+`", "payload": {}}`))
+		req.Header.Set("Content-Type", "application/json")
+		if requestID != "" {
+			req.Header.Set("X-Request-ID", requestID)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := submit("evt_1", "trace-abc-123")
+	if got := rec.Header().Get("X-Request-ID"); got != "trace-abc-123" {
+		t.Errorf("Expected the request ID to round-trip, got %q", got)
+	}
+	if event, _ := application.store.Get("evt_1"); event.RequestID != "trace-abc-123" {
+		t.Errorf("Expected the event to carry the request ID, got %q", event.RequestID)
+	}
+
+	for i, requestID := range []string{"", "has spaces", strings.Repeat("x", maxRequestIDLength+1)} {
+		rec := submit("evt_gen_"+string(rune('a'+i)), requestID)
+		if got := rec.Header().Get("X-Request-ID"); !uuidPattern.MatchString(got) {
+			t.Errorf("Expected a generated UUID for %q, got %q", requestID, got)
+		}
+	}
+
+	// Responses other than submissions carry the ID too
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/health", nil))
+	if !uuidPattern.MatchString(rec.Header().Get("X-Request-ID")) {
+		t.Errorf("Expected a request ID on /health, got %q", rec.Header().Get("X-Request-ID"))
+	}
+}
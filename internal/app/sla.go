@@ -0,0 +1,45 @@
+package app
+
+import (
+	"event-service/internal/model"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// slaWindows are the recent windows GET /stats/sla reports on
+var slaWindows = []struct {
+	name   string
+	length time.Duration
+}{
+	{"1m", time.Minute},
+	{"5m", 5 * time.Minute},
+	{"15m", 15 * time.Minute},
+}
+
+// handleSLAStats reports the fraction of recently processed events that
+// were processed within ?threshold_ms= of being accepted
+func (a *App) handleSLAStats(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		a.writeError(w, r, http.StatusMethodNotAllowed, newAPIError(errMethodNotAllowed))
+		return
+	}
+	thresholdMs, err := strconv.Atoi(r.URL.Query().Get("threshold_ms"))
+	if err != nil || thresholdMs < 1 {
+		a.writeError(w, r, http.StatusBadRequest, newAPIError(errInvalidThreshold))
+		return
+	}
+
+	threshold := time.Duration(thresholdMs) * time.Millisecond
+	resp := model.SLAStatsResponse{ThresholdMs: thresholdMs}
+	for _, window := range slaWindows {
+		processed, met := a.worker.SLACompliance(window.length, threshold)
+		stats := model.SLAWindow{Window: window.name, Processed: processed, WithinThreshold: met}
+		if processed > 0 {
+			compliance := float64(met) / float64(processed)
+			stats.Compliance = &compliance
+		}
+		resp.Windows = append(resp.Windows, stats)
+	}
+	writeResponse(w, r, http.StatusOK, resp)
+}
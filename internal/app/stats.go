@@ -0,0 +1,34 @@
+package app
+
+import (
+	"event-service/internal/model"
+	"net/http"
+	"time"
+)
+
+// handleEventStats handles GET /events/stats, summarizing how long the most
+// recent processing attempts took
+func (a *App) handleEventStats(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		a.writeError(w, r, http.StatusMethodNotAllowed, newAPIError(errMethodNotAllowed))
+		return
+	}
+
+	stats := a.worker.DurationStats()
+	resp := model.EventStatsResponse{
+		Window: stats.Window,
+		Count:  stats.Count,
+		MinMs:  milliseconds(stats.Min),
+		MaxMs:  milliseconds(stats.Max),
+		MeanMs: milliseconds(stats.Mean),
+		P50Ms:  milliseconds(stats.P50),
+		P95Ms:  milliseconds(stats.P95),
+		P99Ms:  milliseconds(stats.P99),
+	}
+	writeResponse(w, r, http.StatusOK, resp)
+}
+
+// milliseconds converts a duration to fractional milliseconds
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
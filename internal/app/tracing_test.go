@@ -0,0 +1,93 @@
+package app
+
+import (
+	"context"
+	"event-service/internal/model"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// useInMemoryTracing installs a tracer provider recording into an in-memory
+// exporter for the duration of the test, then goes back to no-op tracing
+func useInMemoryTracing(t *testing.T) *tracetest.InMemoryExporter {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		provider.Shutdown(context.Background())
+		otel.SetTracerProvider(noop.NewTracerProvider())
+		otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator())
+	})
+	return exporter
+}
+
+func TestTracingFollowsEventToWorker(t *testing.T) {
+	exporter := useInMemoryTracing(t)
+	application := newTestApp(t, Config{ProcessingDelayMs: 0})
+	application.worker.Start()
+	defer application.worker.Stop(context.Background())
+
+	// The gateway in front of the service started the trace
+	gateway := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	req := httptest.NewRequest(http.MethodPost, "/events", strings.NewReader(`{"event_id": "evt_traced"}`))
+	req.Header.Set("traceparent", gateway)
+	rec := httptest.NewRecorder()
+	application.routes().ServeHTTP(rec, req)
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("Expected 202, got %d", rec.Code)
+	}
+	waitForStatus(t, application, "evt_traced", model.StatusProcessed)
+
+	var httpSpan, processSpan *tracetest.SpanStub
+	spans := exporter.GetSpans()
+	for i := range spans {
+		switch spans[i].Name {
+		case "POST /events":
+			httpSpan = &spans[i]
+		case "process event":
+			processSpan = &spans[i]
+		}
+	}
+	if httpSpan == nil || processSpan == nil {
+		t.Fatalf("Expected an HTTP and a processing span, got %d spans", len(spans))
+	}
+
+	if httpSpan.SpanKind != trace.SpanKindServer || httpSpan.Parent.SpanID().String() != "00f067aa0ba902b7" {
+		t.Errorf("Expected a server span continuing the gateway's trace, got kind %s with parent %s", httpSpan.SpanKind, httpSpan.Parent.SpanID())
+	}
+	traceID := httpSpan.SpanContext.TraceID()
+	if traceID.String() != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("Expected the gateway's trace ID, got %s", traceID)
+	}
+	if processSpan.SpanContext.TraceID() != traceID || processSpan.Parent.SpanID() != httpSpan.SpanContext.SpanID() {
+		t.Errorf("Expected the processing span to be a child of the HTTP span, got parent %s", processSpan.Parent.SpanID())
+	}
+	if len(processSpan.Links) != 1 || processSpan.Links[0].SpanContext.SpanID() != httpSpan.SpanContext.SpanID() {
+		t.Errorf("Expected the processing span to link to the HTTP span, got %+v", processSpan.Links)
+	}
+}
+
+func TestEventsCarryNoTraceContextWithoutTracing(t *testing.T) {
+	application := newTestApp(t, Config{WorkerMode: "manual"})
+
+	req := httptest.NewRequest(http.MethodPost, "/events", strings.NewReader(`{"event_id": "evt_untraced"}`))
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	rec := httptest.NewRecorder()
+	application.routes().ServeHTTP(rec, req)
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("Expected 202, got %d", rec.Code)
+	}
+	if event, _ := application.store.Get("evt_untraced"); event.TraceContext != nil {
+		t.Errorf("Expected no trace context without tracing set up, got %v", event.TraceContext)
+	}
+}
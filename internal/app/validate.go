@@ -0,0 +1,172 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"event-service/internal/model"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// validateEventRequest collects every validation problem with a request.
+// The event_id is only checked when one is given, so producers can validate
+// a payload on its own.
+func (a *App) validateEventRequest(req model.EventRequest) []string {
+	var errs []string
+	if req.EventID != "" {
+		if _, err := a.validateEventID(req.EventID); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	if err := a.validatePayload(req.Payload); err != nil {
+		errs = append(errs, "invalid payload: "+err.Error())
+	}
+	if _, err := scheduleTime(req, time.Now()); err != nil {
+		errs = append(errs, err.Error())
+	}
+	if err := validateCallbackURL(req.CallbackURL); err != nil {
+		errs = append(errs, err.Error())
+	}
+	if req.Priority < 0 {
+		errs = append(errs, newAPIError(errNegativePriority).Error())
+	}
+	return errs
+}
+
+// validateCallbackURL accepts an empty callback_url or an absolute http or
+// https URL
+func validateCallbackURL(callbackURL string) error {
+	if callbackURL == "" {
+		return nil
+	}
+	u, err := url.Parse(callbackURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return newAPIError(errInvalidCallbackURL)
+	}
+	return nil
+}
+
+// scheduleTime returns when the event should be processed; the zero time
+// means immediately
+func scheduleTime(req model.EventRequest, now time.Time) (time.Time, error) {
+	if req.ProcessAt != nil && req.DelayMs != 0 {
+		return time.Time{}, newAPIError(errScheduleConflict)
+	}
+	if req.DelayMs < 0 {
+		return time.Time{}, newAPIError(errNegativeDelay)
+	}
+	if req.DelayMs > 0 {
+		return now.Add(time.Duration(req.DelayMs) * time.Millisecond), nil
+	}
+	if req.ProcessAt != nil {
+		return *req.ProcessAt, nil
+	}
+	return time.Time{}, nil
+}
+
+// reservedEventIDs are the routes under /events/, which would shadow GET
+// and DELETE /events/{id} for an event with the same ID
+var reservedEventIDs = map[string]bool{"validate": true, "batch": true, "count": true, "stats": true}
+
+// validateEventID checks the idempotency key, returning the ID to use.
+// Surrounding whitespace is trimmed or rejected depending on configuration.
+func (a *App) validateEventID(eventID string) (string, error) {
+	trimmed := strings.TrimSpace(eventID)
+	if trimmed == "" {
+		return "", newAPIError(errEventIDRequired)
+	}
+	if trimmed != eventID {
+		if a.config.EventIDWhitespace != "trim" {
+			return "", newAPIError(errEventIDWhitespace)
+		}
+		eventID = trimmed
+	}
+	if a.config.MaxEventIDLength > 0 && len(eventID) > a.config.MaxEventIDLength {
+		return "", newAPIError(errEventIDTooLong, a.config.MaxEventIDLength)
+	}
+	if reservedEventIDs[eventID] {
+		return "", newAPIError(errEventIDReserved, eventID)
+	}
+	return eventID, nil
+}
+
+// validatePayload runs all checks on an event payload. An absent payload is
+// valid; see defaultPayload.
+func (a *App) validatePayload(payload json.RawMessage) error {
+	if len(payload) > 0 && !json.Valid(payload) {
+		return errors.New("not well-formed JSON")
+	}
+	return checkPayloadFields(payload, a.config.MaxPayloadFields, a.config.MaxPayloadFieldsNested)
+}
+
+// defaultPayload returns an empty object for an absent or null payload, so
+// every stored event has a JSON payload
+func defaultPayload(payload json.RawMessage) json.RawMessage {
+	if len(bytes.TrimSpace(payload)) == 0 || string(payload) == "null" {
+		return json.RawMessage("{}")
+	}
+	return payload
+}
+
+// fieldFrame tracks one open object or array while streaming a payload
+type fieldFrame struct {
+	object    bool
+	expectKey bool
+	fields    int
+}
+
+// checkPayloadFields streams through the payload tokens and returns an error
+// as soon as an object has more than maxFields fields. Only the top-level
+// object is checked unless nested is set, in which case every object is.
+// It stops early without ever materializing the payload.
+func checkPayloadFields(payload json.RawMessage, maxFields int, nested bool) error {
+	if maxFields <= 0 || len(payload) == 0 {
+		return nil
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(payload))
+	var stack []*fieldFrame
+	for {
+		token, err := dec.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		var top *fieldFrame
+		if len(stack) > 0 {
+			top = stack[len(stack)-1]
+		}
+
+		switch token {
+		case json.Delim('{'), json.Delim('['):
+			if top != nil && top.object {
+				top.expectKey = true
+			}
+			object := token == json.Delim('{')
+			stack = append(stack, &fieldFrame{object: object, expectKey: object})
+		case json.Delim('}'), json.Delim(']'):
+			stack = stack[:len(stack)-1]
+		default:
+			if top == nil || !top.object {
+				continue
+			}
+			if !top.expectKey {
+				// A scalar value; the next token is a key again
+				top.expectKey = true
+				continue
+			}
+			top.fields++
+			top.expectKey = false
+			if top.fields > maxFields && (nested || len(stack) == 1) {
+				return fmt.Errorf("payload object exceeds %d fields", maxFields)
+			}
+		}
+	}
+}
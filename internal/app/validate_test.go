@@ -0,0 +1,135 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// objectWithFields builds a JSON object with n numbered fields
+func objectWithFields(n int) string {
+	fields := make([]string, n)
+	for i := range fields {
+		fields[i] = fmt.Sprintf(`"f%d": %d`, i, i)
+	}
+	return "{" + strings.Join(fields, ",") + "}"
+}
+
+func TestCheckPayloadFields(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload string
+		nested  bool
+		wantErr bool
+	}{
+		{"at limit", objectWithFields(3), false, false},
+		{"over limit", objectWithFields(4), false, true},
+		{"nested values don't count toward top level", `{"a": {"x": 1, "y": 2, "z": 3}, "b": [1, 2, 3, 4], "c": "s"}`, false, false},
+		{"nested object over limit ignored", `{"a": ` + objectWithFields(4) + `}`, false, false},
+		{"nested object over limit checked", `{"a": ` + objectWithFields(4) + `}`, true, true},
+		{"objects in arrays checked when nested", `[` + objectWithFields(4) + `]`, true, true},
+		{"non-object payload", `[1, 2, 3, 4, 5]`, false, false},
+	}
+
+	for _, tt := range tests {
+		err := checkPayloadFields(json.RawMessage(tt.payload), 3, tt.nested)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: expected error=%v, got %v", tt.name, tt.wantErr, err)
+		}
+	}
+}
+
+func TestPayloadValidation(t *testing.T) {
+	application := newTestApp(t, Config{})
+
+	for _, tc := range []struct {
+		name     string
+		payload  json.RawMessage
+		wantErr  bool
+		expected string
+	}{
+		{"object", json.RawMessage(`{"a": 1}`), false, `{"a": 1}`},
+		{"array", json.RawMessage(`[1, 2]`), false, `[1, 2]`},
+		{"absent", nil, false, `{}`},
+		{"null", json.RawMessage(`null`), false, `{}`},
+		{"invalid", json.RawMessage(`{"a": `), true, ""},
+	} {
+		err := application.validatePayload(tc.payload)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("%s: expected error %t, got %v", tc.name, tc.wantErr, err)
+			continue
+		}
+		if !tc.wantErr {
+			if got := string(defaultPayload(tc.payload)); got != tc.expected {
+				t.Errorf("%s: expected payload %s, got %s", tc.name, tc.expected, got)
+			}
+		}
+	}
+}
+
+func TestSubmissionWithoutPayload(t *testing.T) {
+	application := newTestApp(t, Config{})
+
+	rec := httptest.NewRecorder()
+	application.handleEvents(rec, httptest.NewRequest(http.MethodPost, "/events", strings.NewReader(`{"event_id": "no_payload"}`)))
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("Expected 202, got %d", rec.Code)
+	}
+	if event, _ := application.store.Get("no_payload"); string(event.Payload) != `{}` {
+		t.Errorf("Expected an empty object payload, got %s", event.Payload)
+	}
+}
+
+func TestCallbackURLValidation(t *testing.T) {
+	application := newTestApp(t, Config{WorkerMode: "manual"})
+
+	for _, tc := range []struct {
+		callbackURL string
+		code        int
+	}{
+		{"https://example.com/hooks/events", http.StatusAccepted},
+		{"http://localhost:9000/done", http.StatusAccepted},
+		{"ftp://example.com/events", http.StatusBadRequest},
+		{"/relative/path", http.StatusBadRequest},
+		{"https://", http.StatusBadRequest},
+	} {
+		rec := httptest.NewRecorder()
+		body := fmt.Sprintf(`{"event_id": %q, "callback_url": %q}`, tc.callbackURL, tc.callbackURL)
+		application.handleEvents(rec, httptest.NewRequest(http.MethodPost, "/events", strings.NewReader(body)))
+		if rec.Code != tc.code {
+			t.Errorf("%s: expected %d, got %d", tc.callbackURL, tc.code, rec.Code)
+			continue
+		}
+		if tc.code == http.StatusBadRequest {
+			if !strings.Contains(rec.Body.String(), "invalid_callback_url") {
+				t.Errorf("%s: expected invalid_callback_url error, got %s", tc.callbackURL, rec.Body.String())
+			}
+			continue
+		}
+		if event, _ := application.store.Get(tc.callbackURL); event.CallbackURL != tc.callbackURL {
+			t.Errorf("Expected callback_url %s to be stored, got %q", tc.callbackURL, event.CallbackURL)
+		}
+	}
+}
+
+func TestPrioritySubmission(t *testing.T) {
+	application := newTestApp(t, Config{WorkerMode: "manual"})
+
+	rec := httptest.NewRecorder()
+	application.handleEvents(rec, httptest.NewRequest(http.MethodPost, "/events", strings.NewReader(`{"event_id": "urgent", "priority": 3}`)))
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("Expected 202, got %d", rec.Code)
+	}
+	if event, _ := application.store.Get("urgent"); event.Priority != 3 {
+		t.Errorf("Expected priority 3 to be stored, got %d", event.Priority)
+	}
+
+	rec = httptest.NewRecorder()
+	application.handleEvents(rec, httptest.NewRequest(http.MethodPost, "/events", strings.NewReader(`{"event_id": "negative", "priority": -1}`)))
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "negative_priority") {
+		t.Errorf("Expected 400 negative_priority, got %d %s", rec.Code, rec.Body.String())
+	}
+}
@@ -0,0 +1,185 @@
+// Package archive exports processed events to object storage for long-term
+// retention, independent of the operational store.
+package archive
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"event-service/internal/model"
+	"event-service/internal/store"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// Storage writes whole objects to a bucket. It is an interface so tests can
+// use an in-memory fake and deployments can target any S3-compatible service.
+type Storage interface {
+	Put(ctx context.Context, key string, body []byte, contentType string) error
+}
+
+// Config controls how the exporter batches events into objects
+type Config struct {
+	// Prefix is prepended to every object key, e.g. "events/"
+	Prefix string
+	// Interval is how often new events are collected; whatever has been
+	// collected is written as one object at least this often
+	Interval time.Duration
+	// MaxObjectBytes starts a new object once the current one reaches this
+	// size (0 = rotate by time only)
+	MaxObjectBytes int
+	// Timeout bounds each upload
+	Timeout time.Duration
+}
+
+// Exporter periodically writes processed events as JSON Lines objects.
+//
+// It follows the store's change feed, so an event is exported once each
+// time it is seen in the processed state; an event changed again after
+// processing is exported again. Failed uploads are retried on the next
+// interval with the pending lines kept in memory.
+type Exporter struct {
+	store   *store.Store
+	storage Storage
+	config  Config
+
+	mu      sync.Mutex
+	cursor  uint64
+	pending []*bytes.Buffer
+	current *bytes.Buffer
+	objects int
+
+	started bool
+	stop    chan struct{}
+	done    chan struct{}
+}
+
+// New creates an exporter; call Start to begin exporting
+func New(st *store.Store, storage Storage, config Config) *Exporter {
+	if config.Interval <= 0 {
+		config.Interval = time.Minute
+	}
+	return &Exporter{
+		store:   st,
+		storage: storage,
+		config:  config,
+		current: &bytes.Buffer{},
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+}
+
+// Start exports in the background until Stop is called
+func (e *Exporter) Start() {
+	log.Printf("Exporting processed events every %s (prefix: %q)", e.config.Interval, e.config.Prefix)
+	e.started = true
+	go func() {
+		defer close(e.done)
+		ticker := time.NewTicker(e.config.Interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				e.Export()
+			case <-e.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop ends the background loop and makes a final export attempt so events
+// processed during shutdown drain are not left behind
+func (e *Exporter) Stop() {
+	if e.started {
+		close(e.stop)
+		<-e.done
+	}
+	e.Export()
+}
+
+// Export collects newly processed events and uploads every completed object.
+// It returns the number of objects written.
+func (e *Exporter) Export() int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.collect()
+	if e.current.Len() > 0 {
+		e.rotate()
+	}
+
+	written := 0
+	for len(e.pending) > 0 {
+		if err := e.upload(e.pending[0].Bytes()); err != nil {
+			log.Printf("Archive upload failed, %d objects pending: %v", len(e.pending), err)
+			break
+		}
+		e.pending[0] = nil
+		e.pending = e.pending[1:]
+		written++
+	}
+	return written
+}
+
+// collect appends processed events changed since the cursor to the current
+// object, rotating whenever it reaches the size limit. The store hands out
+// copies, so the worker can keep changing the events meanwhile.
+// Caller must hold e.mu.
+func (e *Exporter) collect() {
+	events, cursor := e.store.ListModifiedAfter(e.cursor)
+	e.cursor = cursor
+
+	for _, event := range events {
+		if event.Status != model.StatusProcessed {
+			continue
+		}
+		line, err := json.Marshal(model.ArchivedEvent{
+			EventResponse: model.EventResponse{
+				EventID:       event.EventID,
+				Type:          event.Type,
+				Payload:       event.Payload,
+				Status:        event.Status,
+				CorrelationID: event.CorrelationID,
+				CausationID:   event.CausationID,
+				CreatedAt:     event.CreatedAt,
+				ProcessedAt:   event.ProcessedAt,
+				UpdatedSeq:    event.UpdatedSeq,
+			},
+			History: event.History,
+		})
+		if err != nil {
+			log.Printf("Skipping archive of event %s: %v", event.EventID, err)
+			continue
+		}
+		e.current.Write(line)
+		e.current.WriteByte('\n')
+		if e.config.MaxObjectBytes > 0 && e.current.Len() >= e.config.MaxObjectBytes {
+			e.rotate()
+		}
+	}
+}
+
+// rotate queues the current object for upload and starts a new one.
+// Caller must hold e.mu.
+func (e *Exporter) rotate() {
+	e.pending = append(e.pending, e.current)
+	e.current = &bytes.Buffer{}
+}
+
+func (e *Exporter) upload(body []byte) error {
+	ctx := context.Background()
+	if e.config.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, e.config.Timeout)
+		defer cancel()
+	}
+
+	// The timestamp orders objects; the counter keeps keys unique when
+	// several objects are written within the same instant
+	e.objects++
+	key := fmt.Sprintf("%sevents-%s-%06d.jsonl", e.config.Prefix, time.Now().UTC().Format("20060102T150405.000000000Z"), e.objects)
+	return e.storage.Put(ctx, key, body, "application/x-ndjson")
+}
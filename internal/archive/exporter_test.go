@@ -0,0 +1,109 @@
+package archive
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"event-service/internal/model"
+	"event-service/internal/store"
+	"strings"
+	"testing"
+	"time"
+)
+
+// memoryStorage records uploaded objects and can be made to fail
+type memoryStorage struct {
+	objects map[string][]byte
+	fail    bool
+}
+
+func (m *memoryStorage) Put(ctx context.Context, key string, body []byte, contentType string) error {
+	if m.fail {
+		return errors.New("unavailable")
+	}
+	m.objects[key] = append([]byte(nil), body...)
+	return nil
+}
+
+func TestExport(t *testing.T) {
+	st := store.New()
+	storage := &memoryStorage{objects: make(map[string][]byte)}
+	e := New(st, storage, Config{Prefix: "events/"})
+
+	st.Save(&model.Event{EventID: "a", Status: model.StatusAccepted})
+	st.Save(&model.Event{EventID: "b", Status: model.StatusAccepted})
+	st.MarkProcessed("a")
+
+	if written := e.Export(); written != 1 {
+		t.Fatalf("Expected 1 object, got %d", written)
+	}
+	for key, body := range storage.objects {
+		if !strings.HasPrefix(key, "events/") || !strings.HasSuffix(key, ".jsonl") {
+			t.Errorf("Expected a prefixed .jsonl key, got %s", key)
+		}
+		var archived model.ArchivedEvent
+		if err := json.Unmarshal(bytes.TrimSpace(body), &archived); err != nil {
+			t.Fatalf("Expected a JSON line, got %q", body)
+		}
+		if archived.EventID != "a" || len(archived.History) != 2 {
+			t.Errorf("Expected processed event a with its history, got %+v", archived)
+		}
+	}
+
+	if written := e.Export(); written != 0 {
+		t.Errorf("Expected nothing new to export, got %d objects", written)
+	}
+}
+
+// TestExportWhileEventsChange updates an event while it is being exported;
+// run with -race to catch the exporter reading events the worker changes.
+// The update only waits by sleeping, which -race doesn't count as
+// synchronization, so reads of the live event are reported reliably.
+func TestExportWhileEventsChange(t *testing.T) {
+	st := store.New()
+	storage := &memoryStorage{objects: make(map[string][]byte)}
+	e := New(st, storage, Config{})
+	st.Save(&model.Event{EventID: "a", Status: model.StatusProcessed, Payload: json.RawMessage(`{"v": 1}`)})
+
+	updated := make(chan struct{})
+	go func() {
+		defer close(updated)
+		time.Sleep(50 * time.Millisecond)
+		st.SetPayload("a", json.RawMessage(`{"v": 2}`))
+		st.RecordHistory("a", model.HistoryEntry{Type: model.HistoryProcessed})
+	}()
+	if written := e.Export(); written != 1 {
+		t.Errorf("Expected 1 object, got %d", written)
+	}
+	<-updated
+}
+
+func TestExportRotatesBySize(t *testing.T) {
+	st := store.New()
+	storage := &memoryStorage{objects: make(map[string][]byte)}
+	e := New(st, storage, Config{MaxObjectBytes: 1})
+
+	for _, id := range []string{"a", "b", "c"} {
+		st.Save(&model.Event{EventID: id, Status: model.StatusProcessed})
+	}
+	if written := e.Export(); written != 3 {
+		t.Errorf("Expected one object per event, got %d", written)
+	}
+}
+
+func TestExportRetriesFailedUploads(t *testing.T) {
+	st := store.New()
+	storage := &memoryStorage{objects: make(map[string][]byte), fail: true}
+	e := New(st, storage, Config{})
+
+	st.Save(&model.Event{EventID: "a", Status: model.StatusProcessed})
+	if written := e.Export(); written != 0 {
+		t.Fatalf("Expected no objects while storage fails, got %d", written)
+	}
+
+	storage.fail = false
+	if written := e.Export(); written != 1 {
+		t.Errorf("Expected the pending object on retry, got %d", written)
+	}
+}
@@ -0,0 +1,58 @@
+package archive
+
+import (
+	"context"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestSigningKey(t *testing.T) {
+	// Example from the AWS Signature Version 4 documentation
+	key := signingKey("wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY", "20120215", "us-east-1", "iam")
+	want := "f4780e2d9f65fa895f9c67b32ce1baf0b0d8a43505a000a1a9e090d414db404d"
+	if got := hex.EncodeToString(key); got != want {
+		t.Errorf("Expected signing key %s, got %s", want, got)
+	}
+}
+
+func TestS3Put(t *testing.T) {
+	var path, auth, body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.EscapedPath()
+		auth = r.Header.Get("Authorization")
+		data, _ := io.ReadAll(r.Body)
+		body = string(data)
+	}))
+	defer server.Close()
+
+	storage := NewS3Storage(S3Config{Endpoint: server.URL, Bucket: "archive", AccessKeyID: "AKID", SecretAccessKey: "secret"})
+	if err := storage.Put(context.Background(), "events/a b.jsonl", []byte("{}\n"), "application/x-ndjson"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if path != "/archive/events/a%20b.jsonl" {
+		t.Errorf("Expected path-style escaped key, got %s", path)
+	}
+	if !strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=AKID/") || !strings.Contains(auth, "/us-east-1/s3/aws4_request") {
+		t.Errorf("Expected a SigV4 authorization header, got %q", auth)
+	}
+	if body != "{}\n" {
+		t.Errorf("Expected object body to be uploaded, got %q", body)
+	}
+}
+
+func TestS3PutError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "AccessDenied", http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	storage := NewS3Storage(S3Config{Endpoint: server.URL, Bucket: "archive"})
+	if err := storage.Put(context.Background(), "key", nil, "text/plain"); err == nil {
+		t.Error("Expected an error for a rejected upload")
+	}
+}
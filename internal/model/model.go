@@ -1,32 +1,154 @@
 package model
 
-import "encoding/json"
+import (
+	"encoding/json"
+	"time"
+)
 
 // EventRequest represents the incoming POST /events request body
 type EventRequest struct {
-	EventID string          `json:"event_id"`
-	Payload json.RawMessage `json:"payload"`
+	EventID       string          `json:"event_id"`
+	Type          string          `json:"type,omitempty"`
+	Payload       json.RawMessage `json:"payload"`
+	CorrelationID string          `json:"correlation_id,omitempty"`
+	CausationID   string          `json:"causation_id,omitempty"`
+
+	// ProcessAt (RFC3339) or DelayMs defer processing; at most one may be set
+	ProcessAt *time.Time `json:"process_at,omitempty"`
+	DelayMs   int64      `json:"delay_ms,omitempty"`
+
+	// CallbackURL, when set, receives the event once it is processed or failed
+	CallbackURL string `json:"callback_url,omitempty"`
+
+	// Priority makes the event overtake queued events of lower priority
+	// (0 = normal, higher = more urgent)
+	Priority int `json:"priority,omitempty"`
 }
 
 // EventStatus represents the processing state of an event
 type EventStatus string
 
 const (
-	StatusAccepted  EventStatus = "accepted"
+	StatusAccepted EventStatus = "accepted"
+	// StatusScheduled marks an accepted event waiting for its process_at time
+	StatusScheduled EventStatus = "scheduled"
 	StatusProcessed EventStatus = "processed"
+	// StatusFailed marks an event whose processing failed on every retry
+	StatusFailed EventStatus = "failed"
 )
 
+// Valid reports whether s is one of the known statuses
+func (s EventStatus) Valid() bool {
+	switch s {
+	case StatusAccepted, StatusScheduled, StatusProcessed, StatusFailed:
+		return true
+	}
+	return false
+}
+
 // Event represents an event in the system
 type Event struct {
 	EventID string
+	Type    string
 	Payload json.RawMessage
 	Status  EventStatus
+
+	// CorrelationID groups all events of one business flow; CausationID is
+	// the ID of the event that directly caused this one
+	CorrelationID string
+	CausationID   string
+
+	// ProcessAt is when a scheduled event becomes due (zero = immediately)
+	ProcessAt time.Time
+
+	// Priority orders the event in the queue: higher priorities are
+	// processed first, equal ones in QUEUE_ORDER
+	Priority int
+
+	// DedupKey is the producer-defined dedup key built from payload values;
+	// when set, no two stored events may share it
+	DedupKey string
+
+	// PayloadHash identifies the submitted payload independently of its
+	// formatting, so a resubmission can be told apart from an ID reuse
+	PayloadHash string
+
+	// Attempts is the number of times processing of the event has started
+	Attempts int
+
+	// Checkpoint is the last progress reported by the processor, so
+	// processing interrupted by a crash can resume instead of starting over
+	Checkpoint json.RawMessage
+
+	// CreatedAt is when the event was accepted; ProcessedAt is when it was
+	// processed successfully (nil until then)
+	CreatedAt   time.Time
+	ProcessedAt *time.Time
+
+	// RequestID is the ID of the HTTP request that submitted the event, so
+	// processing can be traced back to the submission
+	RequestID string
+
+	// CallbackURL is notified with an EventCallback once the event is
+	// processed or failed
+	CallbackURL string
+
+	// TraceContext carries the trace of the submitting request (W3C
+	// traceparent and tracestate) so processing joins the same trace
+	TraceContext map[string]string
+
+	// UpdatedSeq is the store-wide sequence number of the last change to
+	// this event. It increases monotonically across all events.
+	UpdatedSeq uint64
+
+	// History is the ordered timeline of everything that happened to the event
+	History []HistoryEntry
+
+	// Transitions are the changes of Status, oldest first
+	Transitions []StatusTransition
+}
+
+// StatusTransition records a change of an event's status. From is empty for
+// the status the event was accepted with.
+type StatusTransition struct {
+	From EventStatus `json:"from,omitempty"`
+	To   EventStatus `json:"to"`
+	At   time.Time   `json:"at"`
+}
+
+// HistoryEntryType identifies what happened in a history entry
+type HistoryEntryType string
+
+const (
+	HistoryAccepted          HistoryEntryType = "accepted"
+	HistoryProcessingStarted HistoryEntryType = "processing_started"
+	HistoryProcessingFailed  HistoryEntryType = "processing_failed"
+	HistoryProcessed         HistoryEntryType = "processed"
+	HistoryFailed            HistoryEntryType = "failed"
+)
+
+// HistoryEntry records a single step in an event's lifecycle
+type HistoryEntry struct {
+	Type  HistoryEntryType `json:"type"`
+	At    time.Time        `json:"at"`
+	Error string           `json:"error,omitempty"`
+}
+
+// ErrorResponse is the body of every API error. Code is stable across
+// languages; Message is localized from the Accept-Language header.
+type ErrorResponse struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
 }
 
 // HealthResponse is returned by GET /health
 type HealthResponse struct {
 	Status string `json:"status"`
 	Uptime string `json:"uptime"`
+	// InflightBytes is the payload size of accepted events not yet processed
+	InflightBytes int64 `json:"inflight_bytes"`
+	QueueDepth    int   `json:"queue_depth"`
+	QueueCapacity int   `json:"queue_capacity"`
 }
 
 // ReadyResponse is returned by GET /ready
@@ -37,7 +159,150 @@ type ReadyResponse struct {
 
 // EventResponse is returned when listing events
 type EventResponse struct {
-	EventID string          `json:"event_id"`
-	Payload json.RawMessage `json:"payload"`
-	Status  EventStatus     `json:"status"`
+	EventID       string          `json:"event_id"`
+	Type          string          `json:"type,omitempty"`
+	Payload       json.RawMessage `json:"payload"`
+	Status        EventStatus     `json:"status"`
+	CorrelationID string          `json:"correlation_id,omitempty"`
+	CausationID   string          `json:"causation_id,omitempty"`
+	ProcessAt     *time.Time      `json:"process_at,omitempty"`
+	Priority      int             `json:"priority,omitempty"`
+	Attempts      int             `json:"attempts,omitempty"`
+	Checkpoint    json.RawMessage `json:"checkpoint,omitempty"`
+	CreatedAt     time.Time       `json:"created_at"`
+	ProcessedAt   *time.Time      `json:"processed_at,omitempty"`
+	UpdatedSeq    uint64          `json:"updated_seq"`
+}
+
+// EventCallback is POSTed to an event's callback_url once it is processed
+// or failed
+type EventCallback struct {
+	EventID       string          `json:"event_id"`
+	Type          string          `json:"type,omitempty"`
+	Status        EventStatus     `json:"status"`
+	Payload       json.RawMessage `json:"payload"`
+	CorrelationID string          `json:"correlation_id,omitempty"`
+	CausationID   string          `json:"causation_id,omitempty"`
+	Attempts      int             `json:"attempts"`
+	ProcessedAt   *time.Time      `json:"processed_at,omitempty"`
+}
+
+// AcceptedResponse is returned by POST /events when the event_id was generated
+type AcceptedResponse struct {
+	EventID string `json:"event_id"`
+}
+
+// EventDetailResponse is returned by GET /events/{id}
+type EventDetailResponse struct {
+	EventResponse
+	Transitions []StatusTransition `json:"transitions"`
+}
+
+// ArchivedEvent is one line of an archive object written by the exporter
+type ArchivedEvent struct {
+	EventResponse
+	History []HistoryEntry `json:"history"`
+}
+
+// Outcomes of one event of a batch submission
+const (
+	BatchAccepted    = "accepted"
+	BatchDuplicate   = "duplicate"
+	BatchInvalid     = "invalid"
+	BatchUnavailable = "unavailable"
+)
+
+// BatchResult is the outcome of one event of POST /events/batch, in the
+// order the events were submitted
+type BatchResult struct {
+	EventID string `json:"event_id"`
+	Status  string `json:"status"`
+	// HTTPStatus is what POST /events would have answered for the event
+	HTTPStatus int            `json:"http_status"`
+	Error      *ErrorResponse `json:"error,omitempty"`
+}
+
+// EventCountsResponse is returned by GET /events/count
+type EventCountsResponse struct {
+	Total     int `json:"total"`
+	Accepted  int `json:"accepted"`
+	Scheduled int `json:"scheduled"`
+	Processed int `json:"processed"`
+	Failed    int `json:"failed"`
+}
+
+// EventSyncResponse is returned by GET /events?modified_after=CURSOR. The
+// cursor is opaque to clients.
+type EventSyncResponse struct {
+	Events []EventResponse `json:"events"`
+	Cursor string          `json:"cursor"`
+}
+
+// EventHistoryResponse is returned by GET /events/{id}/history
+type EventHistoryResponse struct {
+	EventID string         `json:"event_id"`
+	History []HistoryEntry `json:"history"`
+}
+
+// TickResponse is returned by POST /admin/tick
+type TickResponse struct {
+	Processed int `json:"processed"`
+}
+
+// PausedType describes an event type whose processing is paused
+type PausedType struct {
+	Type string `json:"type"`
+	Held int    `json:"held"`
+}
+
+// PausedTypesResponse is returned by GET /debug/paused
+type PausedTypesResponse struct {
+	PausedTypes []PausedType `json:"paused_types"`
+}
+
+// EventStatsResponse is returned by GET /events/stats. Durations are in
+// milliseconds and cover the latest Count processing attempts, at most
+// Window of them.
+type EventStatsResponse struct {
+	Window int     `json:"window"`
+	Count  int     `json:"count"`
+	MinMs  float64 `json:"min_ms"`
+	MaxMs  float64 `json:"max_ms"`
+	MeanMs float64 `json:"mean_ms"`
+	P50Ms  float64 `json:"p50_ms"`
+	P95Ms  float64 `json:"p95_ms"`
+	P99Ms  float64 `json:"p99_ms"`
+}
+
+// SLAWindow reports SLA compliance over one recent window
+type SLAWindow struct {
+	Window          string `json:"window"`
+	Processed       int    `json:"processed"`
+	WithinThreshold int    `json:"within_threshold"`
+	// Compliance is WithinThreshold / Processed, omitted when nothing was processed
+	Compliance *float64 `json:"compliance,omitempty"`
+}
+
+// SLAStatsResponse is returned by GET /stats/sla
+type SLAStatsResponse struct {
+	ThresholdMs int         `json:"threshold_ms"`
+	Windows     []SLAWindow `json:"windows"`
+}
+
+// ServiceSummary is logged on shutdown with the lifetime stats of the instance
+type ServiceSummary struct {
+	Msg             string  `json:"msg"`
+	Accepted        int64   `json:"accepted"`
+	Processed       int64   `json:"processed"`
+	Failed          int64   `json:"failed"`
+	Duplicates      int64   `json:"duplicates_rejected"`
+	AvgProcessingMs float64 `json:"avg_processing_ms"`
+	PeakQueueDepth  int     `json:"peak_queue_depth"`
+	Uptime          string  `json:"uptime"`
+}
+
+// ValidationResponse is returned by POST /events/validate
+type ValidationResponse struct {
+	Valid  bool     `json:"valid"`
+	Errors []string `json:"errors,omitempty"`
 }
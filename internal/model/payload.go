@@ -0,0 +1,24 @@
+package model
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+)
+
+// UnmarshalPayload decodes payload JSON for transformation paths that
+// re-encode it (enrichment, filtering, defaults). Numbers are decoded as
+// json.Number rather than float64, so 64-bit integers and high-precision
+// decimals survive a decode/encode round trip unchanged.
+func UnmarshalPayload(data []byte, v interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(v); err != nil {
+		return err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return errors.New("unexpected data after payload")
+	}
+	return nil
+}
@@ -0,0 +1,36 @@
+package model
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestUnmarshalPayloadPreservesNumbers(t *testing.T) {
+	tests := []string{
+		`{"id":9223372036854775807}`,
+		`{"id":18446744073709551615}`,
+		`{"amount":0.1000000000000000055511151231257827}`,
+		`{"nested":{"values":[12345678901234567890,-9223372036854775808,3.14159265358979323846]}}`,
+	}
+
+	for _, payload := range tests {
+		var decoded interface{}
+		if err := UnmarshalPayload([]byte(payload), &decoded); err != nil {
+			t.Fatalf("Failed to decode %s: %v", payload, err)
+		}
+		encoded, err := json.Marshal(decoded)
+		if err != nil {
+			t.Fatalf("Failed to encode %s: %v", payload, err)
+		}
+		if string(encoded) != payload {
+			t.Errorf("Expected %s to round-trip unchanged, got %s", payload, encoded)
+		}
+	}
+}
+
+func TestUnmarshalPayloadRejectsTrailingData(t *testing.T) {
+	var decoded interface{}
+	if err := UnmarshalPayload([]byte(`{"a":1} {"b":2}`), &decoded); err == nil {
+		t.Error("Expected error for trailing data")
+	}
+}
@@ -0,0 +1,194 @@
+package store
+
+import (
+	"errors"
+	"event-service/internal/model"
+	"fmt"
+	"log"
+)
+
+// ErrPersistence is returned when a change could not be written to the
+// persistent backend
+var ErrPersistence = errors.New("persisting event failed")
+
+// errClosed fails writes that reach the backend after Close
+var errClosed = errors.New("store is closed")
+
+// Backend persists events so they survive a restart. The in-memory store
+// stays the working copy that serves every read: it writes each change
+// through to the backend and is loaded from it on startup. Changes are
+// written in order but after the store's lock is released, so a read may
+// see a change shortly before it is persisted.
+//
+// Backends only store events; Exists, Save, MarkProcessed, GetStatus and
+// List stay on Store, so idempotency and status rules are implemented once
+// rather than per backend.
+type Backend interface {
+	// Save inserts the event or replaces the stored one with the same ID
+	Save(event *model.Event) error
+	// Delete removes the event; deleting a missing event is not an error
+	Delete(eventID string) error
+	// List returns every stored event in insertion order
+	List() ([]*model.Event, error)
+	Close() error
+}
+
+// Claimer is implemented by backends shared between replicas. Claim
+// atomically registers an event ID before the event is accepted, returning
+// false if the ID is already taken, so that only one replica accepts it.
+// Saving or deleting the event keeps or releases the claim.
+type Claimer interface {
+	Claim(eventID string) (bool, error)
+}
+
+// Finder is implemented by backends that can look up a single event. With
+// SetMaxEvents, the store asks it about IDs missing from memory, so that an
+// evicted event still counts as a duplicate. Find returns nil if the event
+// is not stored.
+type Finder interface {
+	Find(eventID string) (*model.Event, error)
+}
+
+// UseBackend loads the events already persisted in backend and writes every
+// later change through to it. It must be called before the store is used.
+func (s *Store) UseBackend(backend Backend) error {
+	events, err := backend.List()
+	if err != nil {
+		return fmt.Errorf("load events: %w", err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, event := range events {
+		s.restore(event)
+	}
+	s.evictOverCap()
+	s.backend = backend
+	log.Printf("Loaded %d persisted events", len(events))
+	return nil
+}
+
+// Persistent reports whether changes are written through to a backend, so
+// that events left in the store survive a restart
+func (s *Store) Persistent() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.backend != nil
+}
+
+// restore adds a persisted event as it was, without recording a new
+// history entry. Caller must hold s.mu.
+func (s *Store) restore(event *model.Event) {
+	s.events[event.EventID] = event
+	s.order = append(s.order, event.EventID)
+	s.index(event)
+	s.touch(event)
+}
+
+// claim registers the event ID with the backend if it is shared between
+// replicas. Caller must not hold s.mu, as claiming is a network round trip.
+func (s *Store) claim(eventID string) (bool, error) {
+	claimer, ok := s.backend.(Claimer)
+	if !ok {
+		return true, nil
+	}
+	claimed, err := claimer.Claim(eventID)
+	if err != nil {
+		log.Printf("Failed to claim event %s: %v", eventID, err)
+		return false, fmt.Errorf("%w: %v", ErrPersistence, err)
+	}
+	return claimed, nil
+}
+
+// unclaim releases a claim for an event that was not accepted after all.
+// Caller must not hold s.mu.
+func (s *Store) unclaim(eventID string) {
+	s.mu.Lock()
+	_, ok := s.backend.(Claimer)
+	var w *write
+	if ok {
+		w = s.queueDelete(eventID)
+	}
+	s.mu.Unlock()
+	s.flush(w)
+}
+
+// write is a change to the backend. Writes are queued while holding s.mu,
+// so they reach the backend in the order of the changes, and applied by
+// flush once it is released: a backend write may be a network round trip,
+// and holding s.mu through it would make every read wait on it.
+type write struct {
+	eventID string
+	// event is the copy to save, or nil to delete the event
+	event *model.Event
+	err   error
+}
+
+// queueSave queues writing a copy of the event through to the backend, if
+// any, and returns the write for flush. Caller must hold s.mu for writing.
+func (s *Store) queueSave(event *model.Event) *write {
+	if s.backend == nil {
+		return s.closedWrite(event.EventID)
+	}
+	w := &write{eventID: event.EventID, event: clone(event)}
+	s.writes = append(s.writes, w)
+	return w
+}
+
+// queueDelete queues deleting the event from the backend, if any, and
+// returns the write for flush. Caller must hold s.mu for writing.
+func (s *Store) queueDelete(eventID string) *write {
+	if s.backend == nil {
+		return s.closedWrite(eventID)
+	}
+	w := &write{eventID: eventID}
+	s.writes = append(s.writes, w)
+	return w
+}
+
+// closedWrite returns an already failed write if the backend has been
+// closed, or nil if the store never had one. Caller must hold s.mu.
+func (s *Store) closedWrite(eventID string) *write {
+	if !s.closed {
+		return nil
+	}
+	return &write{eventID: eventID, err: errClosed}
+}
+
+// flush applies every queued write to the backend and returns the error of
+// w, which must have been queued before; w may be nil. Writes queued by
+// other goroutines are applied along the way, so the backend sees all
+// changes in order. Caller must not hold s.mu.
+func (s *Store) flush(w *write) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	s.applyQueued()
+	if w == nil || w.err == nil {
+		return nil
+	}
+	return fmt.Errorf("%w: %v", ErrPersistence, w.err)
+}
+
+// applyQueued applies the queued writes in order. Caller must hold
+// s.writeMu but not s.mu.
+func (s *Store) applyQueued() {
+	s.mu.Lock()
+	writes, backend := s.writes, s.backend
+	s.writes = nil
+	s.mu.Unlock()
+	for _, w := range writes {
+		if backend == nil {
+			// Queued while Close was closing the backend
+			w.err = errClosed
+			continue
+		}
+		if w.event == nil {
+			if w.err = backend.Delete(w.eventID); w.err != nil {
+				log.Printf("Failed to delete persisted event %s, it may return on restart: %v", w.eventID, w.err)
+			}
+			continue
+		}
+		if w.err = backend.Save(w.event); w.err != nil {
+			log.Printf("Failed to persist event %s: %v", w.eventID, w.err)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"event-service/internal/model"
+	"strconv"
+	"strings"
+)
+
+// ErrFieldNotIndexed is returned when filtering on a payload field that was
+// not declared with SetIndexedFields
+var ErrFieldNotIndexed = errors.New("payload field is not indexed")
+
+// SetIndexedFields declares the payload fields (dot paths such as "user_id"
+// or "order.id") that ListByPayloadFields can filter on. Each event is
+// indexed by its values at these paths when stored, costing some memory and
+// write time per event. It must be called before the store is used,
+// including before UseBackend.
+func (s *Store) SetIndexedFields(fields []string) {
+	s.byField = make(map[string]map[string][]string, len(fields))
+	for _, field := range fields {
+		s.byField[field] = make(map[string][]string)
+	}
+}
+
+// IsIndexed reports whether the payload field was declared with
+// SetIndexedFields
+func (s *Store) IsIndexed(field string) bool {
+	_, ok := s.byField[field]
+	return ok
+}
+
+// ListByPayloadFields returns copies of the events whose payload has the given value
+// at every given field, in the configured list order. Values are compared
+// as text, so "123" matches both the number 123 and the string "123".
+// It returns ErrFieldNotIndexed if any field was not declared.
+func (s *Store) ListByPayloadFields(filters map[string]string) ([]*model.Event, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	// Start from the field with the fewest matches and check the others
+	// against the candidates, so the cost follows the size of the result
+	var candidates []string
+	first := true
+	for field, value := range filters {
+		index, ok := s.byField[field]
+		if !ok {
+			return nil, ErrFieldNotIndexed
+		}
+		if ids := index[value]; first || len(ids) < len(candidates) {
+			candidates, first = ids, false
+		}
+	}
+
+	events := make([]*model.Event, 0, len(candidates))
+	for i := range candidates {
+		id := candidates[i]
+		if s.listOrder == NewestFirst {
+			id = candidates[len(candidates)-1-i]
+		}
+		event := s.events[id]
+		values := fieldValues(event.Payload, filters)
+		matches := true
+		for field, value := range filters {
+			if got, ok := values[field]; !ok || got != value {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			events = append(events, clone(event))
+		}
+	}
+	return events, nil
+}
+
+// indexFields adds the event to the payload field indexes.
+// Caller must hold s.mu.
+func (s *Store) indexFields(event *model.Event) {
+	if len(s.byField) == 0 {
+		return
+	}
+	for field, value := range fieldValues(event.Payload, s.byField) {
+		s.byField[field][value] = append(s.byField[field][value], event.EventID)
+	}
+}
+
+// unindexFields removes the event from the payload field indexes. It must
+// see the payload the event was indexed with. Caller must hold s.mu.
+func (s *Store) unindexFields(event *model.Event) {
+	if len(s.byField) == 0 {
+		return
+	}
+	for field, value := range fieldValues(event.Payload, s.byField) {
+		ids := s.byField[field][value]
+		for i, id := range ids {
+			if id == event.EventID {
+				ids = append(ids[:i], ids[i+1:]...)
+				break
+			}
+		}
+		if len(ids) == 0 {
+			delete(s.byField[field], value)
+		} else {
+			s.byField[field][value] = ids
+		}
+	}
+}
+
+// fieldValues returns the payload's values at the fields that are keys of
+// fields, as text. Fields that are missing or hold an object, an array or
+// null are left out.
+func fieldValues[V any](payload json.RawMessage, fields map[string]V) map[string]string {
+	var root interface{}
+	if len(payload) == 0 || model.UnmarshalPayload(payload, &root) != nil {
+		return nil
+	}
+	values := make(map[string]string, len(fields))
+	for field := range fields {
+		value := root
+		for _, name := range strings.Split(field, ".") {
+			object, ok := value.(map[string]interface{})
+			if !ok {
+				value = nil
+				break
+			}
+			value = object[name]
+		}
+		switch v := value.(type) {
+		case string:
+			values[field] = v
+		case json.Number:
+			values[field] = v.String()
+		case bool:
+			values[field] = strconv.FormatBool(v)
+		}
+	}
+	return values
+}
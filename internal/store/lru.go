@@ -0,0 +1,122 @@
+package store
+
+import (
+	"container/list"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// accessOrder tracks event IDs from most to least recently accessed. It has
+// its own lock so reads under the store's read lock can record an access.
+type accessOrder struct {
+	mu       sync.Mutex
+	recent   *list.List // of event IDs, most recently accessed first
+	elements map[string]*list.Element
+}
+
+func newAccessOrder() *accessOrder {
+	return &accessOrder{recent: list.New(), elements: make(map[string]*list.Element)}
+}
+
+// access marks the event as the most recently accessed
+func (a *accessOrder) access(eventID string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if element, ok := a.elements[eventID]; ok {
+		a.recent.MoveToFront(element)
+		return
+	}
+	a.elements[eventID] = a.recent.PushFront(eventID)
+}
+
+// forget drops the event from the access order
+func (a *accessOrder) forget(eventID string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if element, ok := a.elements[eventID]; ok {
+		a.recent.Remove(element)
+		delete(a.elements, eventID)
+	}
+}
+
+// leastRecent returns the least recently accessed event ID for which keep
+// returns false, or "" if there is none
+func (a *accessOrder) leastRecent(keep func(eventID string) bool) string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for element := a.recent.Back(); element != nil; element = element.Prev() {
+		if eventID := element.Value.(string); !keep(eventID) {
+			return eventID
+		}
+	}
+	return ""
+}
+
+// SetMaxEvents caps the number of events kept in memory. Beyond the cap the
+// least recently accessed processed or failed event is evicted, and with it
+// the memory of its ID: a later submission with the same ID is accepted
+// again. Events that haven't finished processing are never evicted, so the
+// cap can be exceeded while more of them are waiting. A persistent backend
+// keeps evicted events, and if it can look them up (see Finder) or claims
+// IDs (see Claimer) their IDs stay taken. 0 means unlimited. It must be
+// called before the store is used.
+func (s *Store) SetMaxEvents(maxEvents int) {
+	s.maxEvents = maxEvents
+}
+
+// evictOverCap evicts events until the store is back within its cap.
+// Caller must hold s.mu.
+func (s *Store) evictOverCap() {
+	if s.maxEvents <= 0 {
+		return
+	}
+	for len(s.events) > s.maxEvents {
+		eventID := s.accessed.leastRecent(func(eventID string) bool {
+			return !finished(s.events[eventID])
+		})
+		if eventID == "" {
+			return
+		}
+		s.remove(s.events[eventID])
+		s.seq++
+		s.modifiedAt = time.Now()
+	}
+}
+
+// evicted reports whether the backend still holds an event that is not in
+// memory because it was evicted, so that its ID stays taken. An evicted
+// event past the idempotency TTL is deleted instead. Caller must not hold
+// s.mu, as the lookup may be a round trip.
+func (s *Store) evicted(eventID string) (bool, error) {
+	finder, ok := s.backend.(Finder)
+	if !ok || s.maxEvents <= 0 {
+		return false, nil
+	}
+	s.mu.RLock()
+	_, inMemory := s.events[eventID]
+	s.mu.RUnlock()
+	if inMemory {
+		return false, nil
+	}
+	event, err := finder.Find(eventID)
+	if err != nil {
+		log.Printf("Failed to look up event %s in the backend: %v", eventID, err)
+		return false, fmt.Errorf("%w: %v", ErrPersistence, err)
+	}
+	if event == nil {
+		return false, nil
+	}
+	if s.expired(event, time.Now()) {
+		s.mu.Lock()
+		var w *write
+		// Unless a racing submission of the ID got there first
+		if _, inMemory := s.events[eventID]; !inMemory {
+			w = s.queueDelete(eventID)
+		}
+		s.mu.Unlock()
+		return false, s.flush(w)
+	}
+	return true, nil
+}
@@ -0,0 +1,104 @@
+package store
+
+import (
+	"event-service/internal/model"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestMaxEventsEvictsLeastRecentlyAccessed(t *testing.T) {
+	s := New()
+	s.SetMaxEvents(3)
+	for i := 1; i <= 5; i++ {
+		s.Save(&model.Event{EventID: fmt.Sprintf("e%d", i), Status: model.StatusProcessed})
+	}
+
+	for _, id := range []string{"e1", "e2"} {
+		if s.Exists(id) {
+			t.Errorf("Expected %s to be evicted", id)
+		}
+	}
+	for _, id := range []string{"e3", "e4", "e5"} {
+		if !s.Exists(id) {
+			t.Errorf("Expected %s to remain", id)
+		}
+	}
+
+	// Reading e3 makes e4 the least recently accessed
+	s.Get("e3")
+	s.Save(&model.Event{EventID: "e6", Status: model.StatusProcessed})
+	if s.Exists("e4") || !s.Exists("e3") {
+		t.Error("Expected e4 to be evicted instead of the recently read e3")
+	}
+
+	// An evicted ID is accepted again
+	if saved, _ := s.SaveIfAbsent(&model.Event{EventID: "e1", Status: model.StatusAccepted}); !saved {
+		t.Error("Expected an evicted ID to be accepted again")
+	}
+}
+
+func TestMaxEventsKeepsUnfinishedEvents(t *testing.T) {
+	s := New()
+	s.SetMaxEvents(2)
+	s.Save(&model.Event{EventID: "pending", Status: model.StatusAccepted})
+	s.Save(&model.Event{EventID: "done", Status: model.StatusProcessed})
+	s.Save(&model.Event{EventID: "new", Status: model.StatusAccepted})
+	s.Save(&model.Event{EventID: "newer", Status: model.StatusAccepted})
+
+	if s.Exists("done") {
+		t.Error("Expected the processed event to be evicted")
+	}
+	for _, id := range []string{"pending", "new", "newer"} {
+		if !s.Exists(id) {
+			t.Errorf("Expected unfinished event %s to be kept", id)
+		}
+	}
+	if got := len(s.List()); got != 3 {
+		t.Errorf("Expected 3 events, got %d", got)
+	}
+}
+
+func TestEvictedEventStaysDuplicateWithSQLite(t *testing.T) {
+	dsn := filepath.Join(t.TempDir(), "events.db")
+	open := func(maxEvents int, ttl time.Duration) *Store {
+		backend, err := OpenSQLite(dsn)
+		if err != nil {
+			t.Fatalf("Failed to open SQLite: %v", err)
+		}
+		s := New()
+		s.SetMaxEvents(maxEvents)
+		if err := s.UseBackend(backend); err != nil {
+			t.Fatalf("Failed to load events: %v", err)
+		}
+		s.EnableIdempotencyTTL(ttl)
+		return s
+	}
+
+	s := open(1, 0)
+	s.SaveIfAbsent(&model.Event{EventID: "a", Status: model.StatusAccepted, CreatedAt: time.Now()})
+	s.MarkProcessed("a")
+	s.SaveIfAbsent(&model.Event{EventID: "b", Status: model.StatusAccepted, CreatedAt: time.Now()})
+	if _, exists := s.Get("a"); exists {
+		t.Fatal("Expected a to be evicted from memory")
+	}
+
+	if saved, err := s.SaveIfAbsent(&model.Event{EventID: "a", Status: model.StatusAccepted}); saved || err != nil {
+		t.Errorf("Expected the evicted a to still be a duplicate, got %t %v", saved, err)
+	}
+	s.Close()
+
+	s = open(0, 0)
+	if status, _ := s.GetStatus("a"); status != model.StatusProcessed {
+		t.Errorf("Expected the stored a to stay processed, got %s", status)
+	}
+	s.Close()
+
+	// Past the TTL the evicted ID can be reused
+	s = open(1, time.Nanosecond)
+	defer s.Close()
+	if saved, err := s.SaveIfAbsent(&model.Event{EventID: "a", Status: model.StatusAccepted}); !saved || err != nil {
+		t.Errorf("Expected the expired a to be accepted again, got %t %v", saved, err)
+	}
+}
@@ -0,0 +1,334 @@
+package store
+
+import (
+	"bufio"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"event-service/internal/model"
+	"fmt"
+	"io"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// redisTimeout bounds each Redis command, including dialing
+const redisTimeout = 5 * time.Second
+
+// redisSaveScript upserts an event hash. An event keeps the sequence number
+// it got on first save, so lists keep insertion order across replicas.
+const redisSaveScript = `
+local seq = redis.call('HGET', KEYS[1], 'seq')
+if not seq then seq = redis.call('INCR', KEYS[2]) end
+redis.call('HSET', KEYS[1], 'seq', seq, 'status', ARGV[1], 'data', ARGV[2])
+redis.call('SET', KEYS[3], '1')
+return seq`
+
+// RedisConfig says how to reach the Redis server
+type RedisConfig struct {
+	Addr   string
+	Prefix string
+	// Username and Password authenticate with AUTH when Password is set;
+	// Username is only needed for Redis 6 ACL users other than default
+	Username string
+	Password string
+	// TLS encrypts the connection, verifying the server's certificate
+	TLS bool
+}
+
+// RedisBackend persists events in Redis so that several replicas can share
+// them. Under the prefix it keeps:
+//
+//	{prefix}:id:{id}     claim on the event ID, set with SETNX
+//	{prefix}:event:{id}  hash with the event's seq, status and data (JSON)
+//	{prefix}:seq         counter giving events their insertion order
+//
+// The braces are literal: they make the prefix a hash tag, so in Redis
+// Cluster every key lives in the same slot and the save script may touch
+// several of them. The client follows MOVED redirects to the node serving
+// that slot.
+//
+// Claims make acceptance atomic across replicas: only the replica whose
+// SETNX succeeds accepts the event. Everything else stays per replica, as
+// each works from its own in-memory copy loaded on startup (see Claim).
+type RedisBackend struct {
+	conn   *redisConn
+	prefix string
+}
+
+// OpenRedis connects to the Redis server
+func OpenRedis(config RedisConfig) (*RedisBackend, error) {
+	conn := &redisConn{addr: config.Addr, username: config.Username, password: config.Password}
+	if config.TLS {
+		host, _, err := net.SplitHostPort(config.Addr)
+		if err != nil {
+			return nil, err
+		}
+		conn.tls = &tls.Config{ServerName: host, MinVersion: tls.VersionTLS12}
+	}
+	b := &RedisBackend{conn: conn, prefix: "{" + config.Prefix + "}"}
+	if _, err := b.conn.do("PING"); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// Claim atomically registers the event ID, returning false if any replica
+// already holds it
+func (b *RedisBackend) Claim(eventID string) (bool, error) {
+	reply, err := b.conn.do("SETNX", b.idKey(eventID), "1")
+	if err != nil {
+		return false, err
+	}
+	return reply == int64(1), nil
+}
+
+// Save inserts or replaces the event and claims its ID
+func (b *RedisBackend) Save(event *model.Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	_, err = b.conn.do("EVAL", redisSaveScript, "3",
+		b.eventKey(event.EventID), b.prefix+":seq", b.idKey(event.EventID),
+		string(event.Status), string(data))
+	return err
+}
+
+// Delete removes the event and releases its ID
+func (b *RedisBackend) Delete(eventID string) error {
+	_, err := b.conn.do("DEL", b.eventKey(eventID), b.idKey(eventID))
+	return err
+}
+
+// List returns every event in insertion order. It scans the keys under the
+// prefix, so it is meant for loading on startup rather than serving reads.
+func (b *RedisBackend) List() ([]*model.Event, error) {
+	type stored struct {
+		seq   int64
+		event *model.Event
+	}
+	var events []stored
+	cursor := "0"
+	for {
+		reply, err := b.conn.do("SCAN", cursor, "MATCH", b.prefix+":event:*", "COUNT", "100")
+		if err != nil {
+			return nil, err
+		}
+		page, ok := reply.([]interface{})
+		if !ok || len(page) != 2 {
+			return nil, fmt.Errorf("unexpected SCAN reply %v", reply)
+		}
+		keys, _ := page[1].([]interface{})
+		for _, key := range keys {
+			fields, err := b.conn.do("HMGET", key.(string), "seq", "data")
+			if err != nil {
+				return nil, err
+			}
+			values, _ := fields.([]interface{})
+			if len(values) != 2 || values[0] == nil || values[1] == nil {
+				// Deleted since the scan
+				continue
+			}
+			seq, err := strconv.ParseInt(values[0].(string), 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("%s: invalid seq: %w", key, err)
+			}
+			var event model.Event
+			if err := json.Unmarshal([]byte(values[1].(string)), &event); err != nil {
+				return nil, fmt.Errorf("%s: %w", key, err)
+			}
+			events = append(events, stored{seq: seq, event: &event})
+		}
+		if cursor, _ = page[0].(string); cursor == "0" {
+			break
+		}
+	}
+
+	sort.Slice(events, func(i, j int) bool { return events[i].seq < events[j].seq })
+	result := make([]*model.Event, len(events))
+	for i, s := range events {
+		result[i] = s.event
+	}
+	return result, nil
+}
+
+// Close closes the connection
+func (b *RedisBackend) Close() error {
+	return b.conn.close()
+}
+
+func (b *RedisBackend) idKey(eventID string) string {
+	return b.prefix + ":id:" + eventID
+}
+
+func (b *RedisBackend) eventKey(eventID string) string {
+	return b.prefix + ":event:" + eventID
+}
+
+// redisError is an error reply from the server
+type redisError string
+
+func (e redisError) Error() string { return "redis: " + string(e) }
+
+// redisConn is a minimal RESP client over a single connection. Commands are
+// serialized; after a network error the connection is dropped and redialed
+// by the next command.
+type redisConn struct {
+	addr     string
+	username string
+	password string
+	tls      *tls.Config // nil for plain TCP; ServerName is the configured host
+
+	mu     sync.Mutex
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+// do sends a command and returns its reply: a string, an int64, nil, or a
+// []interface{} of those. Error replies are returned as redisError.
+func (c *redisConn) do(args ...string) (interface{}, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	reply, err := c.send(args)
+	var replyErr redisError
+	if errors.As(err, &replyErr) && strings.HasPrefix(string(replyErr), "MOVED ") {
+		// Redis Cluster serves the slot elsewhere: "MOVED <slot> <addr>".
+		// All keys share one slot, so the new node serves every command.
+		if fields := strings.Fields(string(replyErr)); len(fields) == 3 {
+			c.reset()
+			c.addr = fields[2]
+			reply, err = c.send(args)
+		}
+	}
+	return reply, err
+}
+
+// send runs a command, dialing first if needed. Caller must hold c.mu.
+func (c *redisConn) send(args []string) (interface{}, error) {
+	if c.conn == nil {
+		if err := c.dial(); err != nil {
+			return nil, err
+		}
+	}
+
+	c.conn.SetDeadline(time.Now().Add(redisTimeout))
+	reply, err := c.roundTrip(args)
+	var replyErr redisError
+	if err != nil && !errors.As(err, &replyErr) {
+		// The stream may be out of sync; start over with a new connection
+		c.reset()
+	}
+	return reply, err
+}
+
+// dial connects and authenticates. Caller must hold c.mu.
+func (c *redisConn) dial() error {
+	dialer := &net.Dialer{Timeout: redisTimeout}
+	var conn net.Conn
+	var err error
+	if c.tls != nil {
+		// Nodes reached by a MOVED redirect are verified against the
+		// configured host name, as cluster nodes usually share a certificate
+		conn, err = tls.DialWithDialer(dialer, "tcp", c.addr, c.tls)
+	} else {
+		conn, err = dialer.Dial("tcp", c.addr)
+	}
+	if err != nil {
+		return err
+	}
+	c.conn, c.reader = conn, bufio.NewReader(conn)
+
+	if c.password == "" {
+		return nil
+	}
+	auth := []string{"AUTH", c.password}
+	if c.username != "" {
+		auth = []string{"AUTH", c.username, c.password}
+	}
+	c.conn.SetDeadline(time.Now().Add(redisTimeout))
+	if _, err := c.roundTrip(auth); err != nil {
+		c.reset()
+		return fmt.Errorf("redis: authenticate: %w", err)
+	}
+	return nil
+}
+
+// reset drops the connection. Caller must hold c.mu.
+func (c *redisConn) reset() {
+	if c.conn != nil {
+		c.conn.Close()
+	}
+	c.conn, c.reader = nil, nil
+}
+
+func (c *redisConn) roundTrip(args []string) (interface{}, error) {
+	buf := []byte("*" + strconv.Itoa(len(args)) + "\r\n")
+	for _, arg := range args {
+		buf = append(buf, "$"+strconv.Itoa(len(arg))+"\r\n"...)
+		buf = append(buf, arg...)
+		buf = append(buf, "\r\n"...)
+	}
+	if _, err := c.conn.Write(buf); err != nil {
+		return nil, err
+	}
+	return readRESP(c.reader)
+}
+
+// readRESP reads one reply
+func readRESP(r *bufio.Reader) (interface{}, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if len(line) < 3 || line[len(line)-2] != '\r' {
+		return nil, fmt.Errorf("redis: malformed reply %q", line)
+	}
+	kind, body := line[0], line[1:len(line)-2]
+	switch kind {
+	case '+':
+		return body, nil
+	case '-':
+		return nil, redisError(body)
+	case ':':
+		return strconv.ParseInt(body, 10, 64)
+	case '$':
+		n, err := strconv.Atoi(body)
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		data := make([]byte, n+2)
+		if _, err := io.ReadFull(r, data); err != nil {
+			return nil, err
+		}
+		return string(data[:n]), nil
+	case '*':
+		n, err := strconv.Atoi(body)
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		items := make([]interface{}, n)
+		for i := range items {
+			if items[i], err = readRESP(r); err != nil {
+				return nil, err
+			}
+		}
+		return items, nil
+	}
+	return nil, fmt.Errorf("redis: unknown reply type %q", kind)
+}
+
+func (c *redisConn) close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.conn == nil {
+		return nil
+	}
+	err := c.conn.Close()
+	c.conn, c.reader = nil, nil
+	return err
+}
@@ -0,0 +1,127 @@
+package store
+
+import (
+	"bufio"
+	"event-service/internal/model"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeRedis is a RESP server answering each command with reply, recording
+// the commands it received
+type fakeRedis struct {
+	listener net.Listener
+	reply    func(args []string) string
+
+	mu       sync.Mutex
+	commands []string
+}
+
+func startFakeRedis(t *testing.T, reply func(args []string) string) *fakeRedis {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	f := &fakeRedis{listener: listener, reply: reply}
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go f.serve(conn)
+		}
+	}()
+	return f
+}
+
+func (f *fakeRedis) serve(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	for {
+		request, err := readRESP(reader)
+		if err != nil {
+			return
+		}
+		items, _ := request.([]interface{})
+		args := make([]string, len(items))
+		for i, item := range items {
+			args[i], _ = item.(string)
+		}
+		f.mu.Lock()
+		f.commands = append(f.commands, strings.Join(args, " "))
+		f.mu.Unlock()
+		if _, err := conn.Write([]byte(f.reply(args))); err != nil {
+			return
+		}
+	}
+}
+
+func (f *fakeRedis) received() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.commands...)
+}
+
+func (f *fakeRedis) addr() string {
+	return f.listener.Addr().String()
+}
+
+func TestRedisAuthenticatesOnConnect(t *testing.T) {
+	server := startFakeRedis(t, func(args []string) string {
+		if args[0] == "AUTH" && args[len(args)-1] != "s3cret" {
+			return "-WRONGPASS invalid username-password pair\r\n"
+		}
+		return "+OK\r\n"
+	})
+
+	backend, err := OpenRedis(RedisConfig{Addr: server.addr(), Prefix: "p", Username: "events", Password: "s3cret"})
+	if err != nil {
+		t.Fatalf("OpenRedis failed: %v", err)
+	}
+	backend.Close()
+	if got := server.received(); len(got) != 2 || got[0] != "AUTH events s3cret" || got[1] != "PING" {
+		t.Errorf("Expected AUTH before PING, got %q", got)
+	}
+
+	if _, err := OpenRedis(RedisConfig{Addr: server.addr(), Prefix: "p", Password: "wrong"}); err == nil || !strings.Contains(err.Error(), "WRONGPASS") {
+		t.Errorf("Expected the AUTH error, got %v", err)
+	}
+}
+
+func TestRedisKeysShareAClusterSlot(t *testing.T) {
+	// The node the client dials first redirects to the one serving the slot
+	owner := startFakeRedis(t, func(args []string) string {
+		if args[0] == "EVAL" {
+			return ":1\r\n"
+		}
+		return "+OK\r\n"
+	})
+	first := startFakeRedis(t, func(args []string) string {
+		if args[0] == "PING" {
+			return "+PONG\r\n"
+		}
+		return "-MOVED 1234 " + owner.addr() + "\r\n"
+	})
+
+	backend, err := OpenRedis(RedisConfig{Addr: first.addr(), Prefix: "svc"})
+	if err != nil {
+		t.Fatalf("OpenRedis failed: %v", err)
+	}
+	defer backend.Close()
+	if err := backend.Save(&model.Event{EventID: "a", Status: model.StatusAccepted}); err != nil {
+		t.Fatalf("Save after MOVED failed: %v", err)
+	}
+
+	got := owner.received()
+	if len(got) != 1 || !strings.HasPrefix(got[0], "EVAL ") {
+		t.Fatalf("Expected the save to be retried on the slot's node, got %q", got)
+	}
+	if !strings.Contains(got[0], " 3 {svc}:event:a {svc}:seq {svc}:id:a ") {
+		t.Errorf("Expected every key to carry the {svc} hash tag, got %q", got[0])
+	}
+}
@@ -0,0 +1,82 @@
+//go:build redis
+
+package store
+
+import (
+	"encoding/json"
+	"event-service/internal/model"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+)
+
+// Run against a live server with: REDIS_ADDR=localhost:6379 go test -tags redis ./internal/store
+
+func openRedisStore(t *testing.T, prefix string) *Store {
+	t.Helper()
+	addr := os.Getenv("REDIS_ADDR")
+	if addr == "" {
+		addr = "localhost:6379"
+	}
+	backend, err := OpenRedis(RedisConfig{
+		Addr:     addr,
+		Prefix:   prefix,
+		Username: os.Getenv("REDIS_USERNAME"),
+		Password: os.Getenv("REDIS_PASSWORD"),
+		TLS:      os.Getenv("REDIS_TLS") == "true",
+	})
+	if err != nil {
+		t.Fatalf("Failed to connect to Redis at %s: %v", addr, err)
+	}
+	s := New()
+	if err := s.UseBackend(backend); err != nil {
+		t.Fatalf("Failed to load events: %v", err)
+	}
+	return s
+}
+
+func TestRedisBackendSharedBetweenReplicas(t *testing.T) {
+	prefix := fmt.Sprintf("event-service-test-%d", time.Now().UnixNano())
+	replica1 := openRedisStore(t, prefix)
+	replica2 := openRedisStore(t, prefix)
+	defer func() {
+		for _, id := range []string{"a", "b", "c"} {
+			replica1.Delete(id)
+		}
+		replica1.Close()
+		replica2.Close()
+	}()
+
+	if saved, err := replica1.SaveIfAbsent(&model.Event{EventID: "a", Payload: json.RawMessage(`{"n":1}`), Status: model.StatusAccepted}); !saved || err != nil {
+		t.Fatalf("Expected a to be saved on replica 1, got %t %v", saved, err)
+	}
+	if saved, err := replica2.SaveIfAbsent(&model.Event{EventID: "a", Status: model.StatusAccepted}); saved || err != nil {
+		t.Errorf("Expected a to be a duplicate on replica 2, got %t %v", saved, err)
+	}
+	if saved, err := replica2.SaveIfAbsent(&model.Event{EventID: "b", Status: model.StatusAccepted}); !saved || err != nil {
+		t.Fatalf("Expected b to be saved on replica 2, got %t %v", saved, err)
+	}
+	replica2.MarkProcessed("b")
+
+	// An event rejected for its dedup key releases its claim
+	replica1.SaveIfAbsent(&model.Event{EventID: "c", DedupKey: "k", Status: model.StatusAccepted})
+	if saved, _ := replica1.SaveIfAbsent(&model.Event{EventID: "d", DedupKey: "k", Status: model.StatusAccepted}); saved {
+		t.Fatal("Expected d to be a dedup-key duplicate")
+	}
+	if saved, err := replica2.SaveIfAbsent(&model.Event{EventID: "d", Status: model.StatusAccepted}); !saved || err != nil {
+		t.Errorf("Expected the claim on d to be released, got %t %v", saved, err)
+	}
+	replica2.Delete("d")
+
+	// A replica started later loads what both have written
+	replica3 := openRedisStore(t, prefix)
+	defer replica3.Close()
+	events := replica3.List()
+	if len(events) != 3 || events[0].EventID != "a" || string(events[0].Payload) != `{"n":1}` {
+		t.Fatalf("Expected a, b and c in insertion order, got %+v", events)
+	}
+	if status, _ := replica3.GetStatus("b"); status != model.StatusProcessed {
+		t.Errorf("Expected b to be processed, got %s", status)
+	}
+}
@@ -0,0 +1,96 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ErrIdempotencyUnavailable is returned when the external idempotency service
+// cannot be reached and the failure policy is to fail closed
+var ErrIdempotencyUnavailable = errors.New("idempotency service unavailable")
+
+// FailurePolicy decides what happens when the idempotency service errors or times out
+type FailurePolicy string
+
+const (
+	// FailClosed rejects the event when the service cannot answer
+	FailClosed FailurePolicy = "closed"
+	// FailOpen accepts the event based on local state only
+	FailOpen FailurePolicy = "open"
+)
+
+// IdempotencyService is a client for a shared HTTP dedup service.
+//
+// The service is expected to expose one resource per idempotency key:
+//
+//	GET {base}/keys/{id}  -> 200 if the key is known, 404 otherwise
+//	PUT {base}/keys/{id}  -> 201 if the key was claimed, 409 if it already existed
+//
+// Only keys are shared; event data stays in the local store.
+type IdempotencyService struct {
+	baseURL string
+	client  *http.Client
+	policy  FailurePolicy
+}
+
+// NewIdempotencyService creates a client for the dedup service at baseURL
+func NewIdempotencyService(baseURL string, timeout time.Duration, policy FailurePolicy) *IdempotencyService {
+	if policy != FailClosed && policy != FailOpen {
+		log.Printf("Unknown idempotency failure policy %q, using default: %s", policy, FailClosed)
+		policy = FailClosed
+	}
+	return &IdempotencyService{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		client:  &http.Client{Timeout: timeout},
+		policy:  policy,
+	}
+}
+
+// exists reports whether the key is known to the service
+func (s *IdempotencyService) exists(eventID string) (bool, error) {
+	resp, err := s.client.Get(s.keyURL(eventID))
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return true, nil
+	case http.StatusNotFound:
+		return false, nil
+	default:
+		return false, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+}
+
+// claim atomically registers the key, returning false if it was already taken
+func (s *IdempotencyService) claim(eventID string) (bool, error) {
+	req, err := http.NewRequest(http.MethodPut, s.keyURL(eventID), nil)
+	if err != nil {
+		return false, err
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusCreated, http.StatusNoContent:
+		return true, nil
+	case http.StatusConflict:
+		return false, nil
+	default:
+		return false, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+}
+
+func (s *IdempotencyService) keyURL(eventID string) string {
+	return s.baseURL + "/keys/" + url.PathEscape(eventID)
+}
@@ -0,0 +1,83 @@
+package store
+
+import (
+	"event-service/internal/model"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newDedupServer starts a fake idempotency service backed by a map
+func newDedupServer() *httptest.Server {
+	var mu sync.Mutex
+	keys := make(map[string]bool)
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := strings.TrimPrefix(r.URL.Path, "/keys/")
+		mu.Lock()
+		defer mu.Unlock()
+
+		switch r.Method {
+		case http.MethodGet:
+			if !keys[key] {
+				w.WriteHeader(http.StatusNotFound)
+			}
+		case http.MethodPut:
+			if keys[key] {
+				w.WriteHeader(http.StatusConflict)
+				return
+			}
+			keys[key] = true
+			w.WriteHeader(http.StatusCreated)
+		}
+	}))
+}
+
+func TestSaveIfAbsentWithIdempotencyService(t *testing.T) {
+	server := newDedupServer()
+	defer server.Close()
+
+	// Two stores sharing one service behave like two replicas
+	first := NewWithIdempotencyService(NewIdempotencyService(server.URL, time.Second, FailClosed))
+	second := NewWithIdempotencyService(NewIdempotencyService(server.URL, time.Second, FailClosed))
+
+	saved, err := first.SaveIfAbsent(&model.Event{EventID: "evt_1"})
+	if err != nil || !saved {
+		t.Fatalf("Expected first save to succeed, got saved=%v err=%v", saved, err)
+	}
+
+	saved, err = second.SaveIfAbsent(&model.Event{EventID: "evt_1"})
+	if err != nil || saved {
+		t.Errorf("Expected duplicate on second replica, got saved=%v err=%v", saved, err)
+	}
+	if !second.Exists("evt_1") {
+		t.Error("Expected second replica to see evt_1 via the idempotency service")
+	}
+	if second.Exists("evt_2") {
+		t.Error("Expected evt_2 to be unknown")
+	}
+}
+
+func TestIdempotencyServiceFailurePolicy(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(100 * time.Millisecond)
+	}))
+	defer server.Close()
+
+	closed := NewWithIdempotencyService(NewIdempotencyService(server.URL, 10*time.Millisecond, FailClosed))
+	if _, err := closed.SaveIfAbsent(&model.Event{EventID: "evt_1"}); err != ErrIdempotencyUnavailable {
+		t.Errorf("Expected ErrIdempotencyUnavailable when failing closed, got %v", err)
+	}
+	if !closed.Exists("evt_2") {
+		t.Error("Expected Exists to report true when failing closed")
+	}
+
+	open := NewWithIdempotencyService(NewIdempotencyService(server.URL, 10*time.Millisecond, FailOpen))
+	saved, err := open.SaveIfAbsent(&model.Event{EventID: "evt_1"})
+	if err != nil || !saved {
+		t.Errorf("Expected save to succeed when failing open, got saved=%v err=%v", saved, err)
+	}
+}
@@ -0,0 +1,85 @@
+package store
+
+import (
+	"event-service/internal/model"
+	"log"
+	"time"
+)
+
+// snapshot is an immutable copy of the events along with the version of the
+// store it was taken at
+type snapshot struct {
+	events     []*model.Event
+	seq        uint64
+	modifiedAt time.Time
+}
+
+// EnableReadSnapshots serves List from an immutable copy of the events that
+// is rebuilt every interval instead of reading the live map under the lock.
+//
+// List callers then never touch s.mu, so frequent full-list polling doesn't
+// contend with writers; only the refresh holds the read lock, once per
+// interval. The tradeoff is that List can be up to one interval stale.
+// Call Close to stop the refresh.
+func (s *Store) EnableReadSnapshots(interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	s.refreshSnapshot()
+
+	stop := make(chan struct{})
+	s.mu.Lock()
+	s.stopSnapshots = stop
+	s.mu.Unlock()
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				s.refreshSnapshot()
+			case <-stop:
+				return
+			}
+		}
+	}()
+	log.Printf("Serving event lists from snapshots refreshed every %s", interval)
+}
+
+// Close stops the snapshot refresh and the expiry sweep, if enabled, and
+// closes the backend once the writes queued for it are applied. Writes
+// after that fail with ErrPersistence.
+func (s *Store) Close() {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	s.applyQueued()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stopSnapshots != nil {
+		close(s.stopSnapshots)
+		s.stopSnapshots = nil
+	}
+	if s.stopSweeper != nil {
+		close(s.stopSweeper)
+		s.stopSweeper = nil
+	}
+	if s.backend != nil {
+		if err := s.backend.Close(); err != nil {
+			log.Printf("Failed to close store backend: %v", err)
+		}
+		s.backend = nil
+		s.closed = true
+	}
+}
+
+// refreshSnapshot copies every event, in list order, into a new snapshot
+// and publishes it. Events are copied so later writes never show up in a
+// published snapshot.
+func (s *Store) refreshSnapshot() {
+	s.mu.RLock()
+	events := s.listRange(0, len(s.order))
+	seq, modifiedAt := s.seq, s.modifiedAt
+	s.mu.RUnlock()
+	s.snapshot.Store(&snapshot{events: events, seq: seq, modifiedAt: modifiedAt})
+}
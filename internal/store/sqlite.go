@@ -0,0 +1,108 @@
+package store
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"event-service/internal/model"
+	"fmt"
+
+	_ "modernc.org/sqlite"
+)
+
+// sqliteSchema creates the events table. Events are stored whole as JSON;
+// status is kept in its own column so the database can be inspected.
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS events (
+	seq      INTEGER PRIMARY KEY AUTOINCREMENT,
+	event_id TEXT NOT NULL UNIQUE,
+	status   TEXT NOT NULL,
+	data     TEXT NOT NULL
+)`
+
+// SQLiteBackend persists events in a SQLite database
+type SQLiteBackend struct {
+	db *sql.DB
+}
+
+// OpenSQLite opens the SQLite database at dsn, e.g. a file path, and
+// creates the events table if it doesn't exist yet
+func OpenSQLite(dsn string) (*SQLiteBackend, error) {
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, err
+	}
+	// SQLite has a single writer, and an in-memory database only lives as
+	// long as its connection
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("create schema: %w", err)
+	}
+	return &SQLiteBackend{db: db}, nil
+}
+
+// Save inserts or replaces the event. A replaced event keeps its position.
+func (b *SQLiteBackend) Save(event *model.Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	_, err = b.db.Exec(
+		`INSERT INTO events (event_id, status, data) VALUES (?, ?, ?)
+		ON CONFLICT (event_id) DO UPDATE SET status = excluded.status, data = excluded.data`,
+		event.EventID, string(event.Status), string(data),
+	)
+	return err
+}
+
+// Find returns the stored event, or nil if there is none
+func (b *SQLiteBackend) Find(eventID string) (*model.Event, error) {
+	var data string
+	err := b.db.QueryRow(`SELECT data FROM events WHERE event_id = ?`, eventID).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var event model.Event
+	if err := json.Unmarshal([]byte(data), &event); err != nil {
+		return nil, err
+	}
+	return &event, nil
+}
+
+// Delete removes the event
+func (b *SQLiteBackend) Delete(eventID string) error {
+	_, err := b.db.Exec(`DELETE FROM events WHERE event_id = ?`, eventID)
+	return err
+}
+
+// List returns every event in insertion order
+func (b *SQLiteBackend) List() ([]*model.Event, error) {
+	rows, err := b.db.Query(`SELECT data FROM events ORDER BY seq`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []*model.Event
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return nil, err
+		}
+		var event model.Event
+		if err := json.Unmarshal([]byte(data), &event); err != nil {
+			return nil, err
+		}
+		events = append(events, &event)
+	}
+	return events, rows.Err()
+}
+
+// Close closes the database
+func (b *SQLiteBackend) Close() error {
+	return b.db.Close()
+}
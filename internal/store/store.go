@@ -1,50 +1,449 @@
 package store
 
 import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"event-service/internal/model"
+	"log"
+	"sort"
+	"strconv"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
+// ErrNotFound is returned when an operation targets an event that is not stored
+var ErrNotFound = errors.New("event not found")
+
 // Store provides in-memory storage for event idempotency tracking.
 //
-// LIMITATION: This is a simple in-memory store with no persistence.
-// All state will be lost when the service restarts.
-// In production, this would need to be backed by a durable data store
-// like PostgreSQL, Redis, or similar.
+// LIMITATION: Without a Backend (see UseBackend) nothing is persisted and
+// all state is lost when the service restarts.
 type Store struct {
-	mu     sync.RWMutex
-	events map[string]*model.Event
+	mu      sync.RWMutex
+	events  map[string]*model.Event
+	remote  *IdempotencyService
+	backend Backend
+	// closed is set once Close has closed the backend; later writes fail
+	// instead of silently going unpersisted
+	closed bool
+
+	// writes are the backend writes queued under mu and not yet applied;
+	// writeMu serializes applying them (see flush)
+	writes  []*write
+	writeMu sync.Mutex
+
+	// order lists event IDs in insertion order so lists and pages are stable;
+	// listOrder says which end List starts from
+	order     []string
+	listOrder ListOrder
+
+	// seq is the last assigned update sequence; changes holds one entry per
+	// change in sequence order so ListModifiedAfter doesn't scan every event;
+	// modifiedAt is when seq was last assigned; epoch identifies this
+	// store instance, since seq starts over from 0 in each one
+	seq        uint64
+	epoch      string
+	changes    []change
+	modifiedAt time.Time
+
+	// byCorrelation lists event IDs per correlation_id in insertion order
+	byCorrelation map[string][]string
+	// byDedupKey maps each composite dedup key to the event holding it
+	byDedupKey map[string]string
+	// byField lists event IDs per value of each indexed payload field, in
+	// insertion order (see SetIndexedFields)
+	byField map[string]map[string][]string
+
+	// snapshot, when read snapshots are enabled, is the immutable copy List
+	// serves from; stopSnapshots ends its refresh loop
+	snapshot      atomic.Pointer[snapshot]
+	stopSnapshots chan struct{}
+
+	// ttl, when set, is how long a finished event holds its ID (see
+	// EnableIdempotencyTTL); stopSweeper ends the eviction loop
+	ttl         time.Duration
+	stopSweeper chan struct{}
+
+	// maxEvents caps the events kept in memory (see SetMaxEvents); accessed
+	// orders them by last access for eviction
+	maxEvents int
+	accessed  *accessOrder
+}
+
+// ListOrder controls the order in which List and ListPaged return events
+type ListOrder string
+
+const (
+	// OldestFirst lists events in the order they were inserted
+	OldestFirst ListOrder = "oldest"
+	// NewestFirst lists the most recently inserted event first
+	NewestFirst ListOrder = "newest"
+)
+
+// change records that an event was modified at a given update sequence
+type change struct {
+	seq     uint64
+	eventID string
 }
 
 // New creates a new in-memory store
 func New() *Store {
 	return &Store{
-		events: make(map[string]*model.Event),
+		events:        make(map[string]*model.Event),
+		listOrder:     OldestFirst,
+		epoch:         newEpoch(),
+		byCorrelation: make(map[string][]string),
+		byDedupKey:    make(map[string]string),
+		accessed:      newAccessOrder(),
+	}
+}
+
+// newEpoch returns a random ID for a new store instance, falling back to
+// the start time if no randomness is available
+func newEpoch() string {
+	var b [8]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 16)
 	}
+	return hex.EncodeToString(b[:])
+}
+
+// Epoch identifies this store instance. Update sequences are only
+// comparable between values from the same epoch: they start over from 0
+// when the service restarts, and every replica counts on its own.
+func (s *Store) Epoch() string {
+	return s.epoch
+}
+
+// SetListOrder sets the order of List and ListPaged. An empty or unknown
+// order falls back to OldestFirst. It must be called before the store is used.
+func (s *Store) SetListOrder(order ListOrder) {
+	if order == "" {
+		order = OldestFirst
+	} else if order != OldestFirst && order != NewestFirst {
+		log.Printf("Unknown list order %q, using default: %s", order, OldestFirst)
+		order = OldestFirst
+	}
+	s.listOrder = order
+}
+
+// NewWithIdempotencyService creates an in-memory store whose idempotency
+// checks are delegated to a shared external service. Event data stays local.
+func NewWithIdempotencyService(remote *IdempotencyService) *Store {
+	s := New()
+	s.remote = remote
+	return s
 }
 
 // Exists checks if an event with the given ID has already been accepted
 func (s *Store) Exists(eventID string) bool {
 	s.mu.RLock()
-	defer s.mu.RUnlock()
-	_, exists := s.events[eventID]
+	event, exists := s.events[eventID]
+	exists = exists && !s.expired(event, time.Now())
+	s.mu.RUnlock()
+	if exists || s.remote == nil {
+		return exists
+	}
+
+	exists, err := s.remote.exists(eventID)
+	if err != nil {
+		log.Printf("Idempotency service check failed for %s: %v (policy: %s)", eventID, err, s.remote.policy)
+		// Failing closed treats the key as taken so the event is rejected
+		return s.remote.policy == FailClosed
+	}
 	return exists
 }
 
-// Save stores an event with the given status
+// SaveIfAbsent atomically stores the event unless one with the same ID, or
+// the same DedupKey, already exists. It returns false if the event was a
+// duplicate.
+//
+// With an idempotency service configured, the key is claimed remotely first.
+// If the service cannot answer, ErrIdempotencyUnavailable is returned when
+// failing closed; when failing open the local state alone decides.
+func (s *Store) SaveIfAbsent(event *model.Event) (bool, error) {
+	if s.remote != nil {
+		claimed, err := s.remote.claim(event.EventID)
+		if err != nil {
+			log.Printf("Idempotency service claim failed for %s: %v (policy: %s)", event.EventID, err, s.remote.policy)
+			if s.remote.policy == FailClosed {
+				return false, ErrIdempotencyUnavailable
+			}
+		} else if !claimed {
+			return false, nil
+		}
+	}
+	if s.ttl > 0 {
+		// Evicting an expired event releases its claim on the ID
+		s.mu.Lock()
+		s.live(event.EventID)
+		s.mu.Unlock()
+		s.flush(nil)
+	}
+	if claimed, err := s.claim(event.EventID); err != nil || !claimed {
+		return false, err
+	}
+	if evicted, err := s.evicted(event.EventID); err != nil || evicted {
+		return false, err
+	}
+
+	s.mu.Lock()
+	if _, exists := s.live(event.EventID); exists {
+		s.mu.Unlock()
+		return false, nil
+	}
+	if holder, exists := s.byDedupKey[event.DedupKey]; exists && event.DedupKey != "" {
+		if _, exists := s.live(holder); exists {
+			s.mu.Unlock()
+			s.unclaim(event.EventID)
+			return false, nil
+		}
+	}
+	s.insert(event)
+	w := s.queueSave(event)
+	s.mu.Unlock()
+	if err := s.flush(w); err != nil {
+		// An event that can't be persisted isn't accepted
+		s.mu.Lock()
+		if s.events[event.EventID] == event {
+			s.remove(event)
+		}
+		s.mu.Unlock()
+		s.unclaim(event.EventID)
+		return false, err
+	}
+	return true, nil
+}
+
+// Save stores an event with the given status. A failure to persist it is
+// only logged.
 func (s *Store) Save(event *model.Event) {
 	s.mu.Lock()
-	defer s.mu.Unlock()
-	s.events[event.EventID] = event
+	s.insert(event)
+	w := s.queueSave(event)
+	s.mu.Unlock()
+	s.flush(w)
 }
 
-// MarkProcessed updates the event status to processed
-func (s *Store) MarkProcessed(eventID string) {
+// update applies change to the stored event under the lock and then, if
+// change returns true, writes the event through to the backend after
+// releasing it. It returns ErrNotFound if the event is not in the store.
+func (s *Store) update(eventID string, change func(event *model.Event) bool) error {
 	s.mu.Lock()
-	defer s.mu.Unlock()
-	if event, exists := s.events[eventID]; exists {
-		event.Status = model.StatusProcessed
+	event, exists := s.events[eventID]
+	if !exists {
+		s.mu.Unlock()
+		return ErrNotFound
+	}
+	var w *write
+	if change(event) {
+		w = s.queueSave(event)
+	}
+	s.mu.Unlock()
+	return s.flush(w)
+}
+
+// MarkProcessed updates the event status to processed.
+// It returns ErrNotFound if the event is not in the store.
+func (s *Store) MarkProcessed(eventID string) error {
+	return s.update(eventID, func(event *model.Event) bool {
+		// A retry after a failed persist only needs to persist again
+		if event.Status != model.StatusProcessed {
+			now := time.Now()
+			s.transition(event, model.StatusProcessed, now)
+			event.ProcessedAt = &now
+			s.touch(event)
+			s.record(event, model.HistoryEntry{Type: model.HistoryProcessed})
+		}
+		return true
+	})
+}
+
+// MarkFailed updates the event status to failed once processing has run
+// out of retries.
+// It returns ErrNotFound if the event is not in the store.
+func (s *Store) MarkFailed(eventID string) error {
+	return s.update(eventID, func(event *model.Event) bool {
+		if event.Status != model.StatusFailed {
+			s.transition(event, model.StatusFailed, time.Now())
+			s.touch(event)
+			s.record(event, model.HistoryEntry{Type: model.HistoryFailed})
+		}
+		return true
+	})
+}
+
+// RecordAttempt counts the start of a processing attempt and returns the
+// number of attempts so far.
+// It returns ErrNotFound if the event is not in the store.
+func (s *Store) RecordAttempt(eventID string) (int, error) {
+	attempts := 0
+	err := s.update(eventID, func(event *model.Event) bool {
+		event.Attempts++
+		s.touch(event)
+		attempts = event.Attempts
+		return true
+	})
+	return attempts, err
+}
+
+// ScheduleRetry sets when a failed event is due for its next attempt. The
+// event stays accepted so it is recovered if the service stops first.
+// It returns ErrNotFound if the event is not in the store.
+func (s *Store) ScheduleRetry(eventID string, at time.Time) error {
+	return s.update(eventID, func(event *model.Event) bool {
+		event.ProcessAt = at
+		s.touch(event)
+		return true
+	})
+}
+
+// MarkDue moves a scheduled event back to accepted once its time has come.
+// It returns ErrNotFound if the event is not in the store.
+func (s *Store) MarkDue(eventID string) error {
+	return s.update(eventID, func(event *model.Event) bool {
+		if event.Status == model.StatusScheduled {
+			s.transition(event, model.StatusAccepted, time.Now())
+			s.touch(event)
+		}
+		return true
+	})
+}
+
+// SetPayload replaces the payload of a stored event.
+// It returns ErrNotFound if the event is not in the store.
+func (s *Store) SetPayload(eventID string, payload json.RawMessage) error {
+	return s.update(eventID, func(event *model.Event) bool {
+		s.unindexFields(event)
+		event.Payload = payload
+		s.indexFields(event)
+		s.touch(event)
+		return true
+	})
+}
+
+// UpdatePayload replaces the payload of an event that has not been
+// processed yet. It returns false, leaving the event unchanged, if the event
+// is already processed or failed. The event's dedup key and payload hash
+// still describe the payload it was submitted with.
+// It returns ErrNotFound if the event is not in the store.
+func (s *Store) UpdatePayload(eventID string, payload json.RawMessage) (bool, error) {
+	updated := false
+	err := s.update(eventID, func(event *model.Event) bool {
+		if event.Status == model.StatusProcessed || event.Status == model.StatusFailed {
+			return false
+		}
+		s.unindexFields(event)
+		event.Payload = append(json.RawMessage(nil), payload...)
+		s.indexFields(event)
+		s.touch(event)
+		updated = true
+		return true
+	})
+	return updated, err
+}
+
+// SaveCheckpoint stores the processor's latest progress for an event,
+// replacing any earlier checkpoint. The data is copied.
+// It returns ErrNotFound if the event is not in the store.
+func (s *Store) SaveCheckpoint(eventID string, data json.RawMessage) error {
+	return s.update(eventID, func(event *model.Event) bool {
+		event.Checkpoint = append(json.RawMessage(nil), data...)
+		s.touch(event)
+		return true
+	})
+}
+
+// RecordHistory appends an entry to the event's timeline.
+// It returns ErrNotFound if the event is not in the store.
+func (s *Store) RecordHistory(eventID string, entry model.HistoryEntry) error {
+	return s.update(eventID, func(event *model.Event) bool {
+		s.record(event, entry)
+		return true
+	})
+}
+
+// History returns a copy of the event's timeline, oldest entry first
+func (s *Store) History(eventID string) ([]model.HistoryEntry, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	event, exists := s.events[eventID]
+	if !exists {
+		return nil, false
+	}
+	s.accessed.access(eventID)
+	history := make([]model.HistoryEntry, len(event.History))
+	copy(history, event.History)
+	return history, true
+}
+
+// Get returns a copy of the stored event. The copy is taken under the read
+// lock, so callers never observe a half-applied update and can't change the
+// stored event through it.
+func (s *Store) Get(eventID string) (*model.Event, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	event, exists := s.events[eventID]
+	if !exists {
+		return nil, false
+	}
+	s.accessed.access(eventID)
+	return clone(event), true
+}
+
+// cloneAll returns a new slice holding copies of the events, so callers
+// can't change a published snapshot through it
+func cloneAll(events []*model.Event) []*model.Event {
+	copied := make([]*model.Event, len(events))
+	for i, event := range events {
+		copied[i] = clone(event)
+	}
+	return copied
+}
+
+// clone returns a copy of the event that shares no mutable state with it
+func clone(event *model.Event) *model.Event {
+	copied := *event
+	copied.Payload = append(json.RawMessage(nil), event.Payload...)
+	copied.Checkpoint = append(json.RawMessage(nil), event.Checkpoint...)
+	copied.History = append([]model.HistoryEntry(nil), event.History...)
+	copied.Transitions = append([]model.StatusTransition(nil), event.Transitions...)
+	if event.ProcessedAt != nil {
+		processedAt := *event.ProcessedAt
+		copied.ProcessedAt = &processedAt
+	}
+	return &copied
+}
+
+// Delete removes an event and returns whether it existed. The deletion
+// changes the store version, so conditional list requests see it, but it
+// is not reported by ListModifiedAfter. With an idempotency service
+// configured the event ID stays claimed there. If the deletion can't be
+// persisted an ErrPersistence error is returned, though the event is gone
+// from memory; it may return on restart.
+func (s *Store) Delete(eventID string) (bool, error) {
+	s.mu.Lock()
+	event, exists := s.events[eventID]
+	var w *write
+	if exists {
+		w = s.drop(event)
 	}
+	s.mu.Unlock()
+	return exists, s.flush(w)
+}
+
+// drop removes the event from memory, queues deleting it from the backend
+// and changes the store version. It returns the queued write; caller must
+// hold s.mu and flush after releasing it.
+func (s *Store) drop(event *model.Event) *write {
+	s.remove(event)
+	w := s.queueDelete(event.EventID)
+	s.seq++
+	s.modifiedAt = time.Now()
+	return w
 }
 
 // GetStatus returns the current status of an event
@@ -52,18 +451,251 @@ func (s *Store) GetStatus(eventID string) (model.EventStatus, bool) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 	if event, exists := s.events[eventID]; exists {
+		s.accessed.access(eventID)
 		return event.Status, true
 	}
 	return "", false
 }
 
-// List returns all events in the store
+// List returns copies of all events in the store in the configured list
+// order, like Get. With read snapshots enabled the result comes from the
+// latest snapshot and may be slightly stale.
 func (s *Store) List() []*model.Event {
+	if snapshot := s.snapshot.Load(); snapshot != nil {
+		return cloneAll(snapshot.events)
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.listRange(0, len(s.order))
+}
+
+// ListPaged returns copies of up to limit events starting at offset, in the
+// configured list order, along with the total number of events. With read
+// snapshots enabled the page comes from the latest snapshot and may be
+// slightly stale.
+func (s *Store) ListPaged(limit, offset int) ([]*model.Event, int) {
+	if snapshot := s.snapshot.Load(); snapshot != nil {
+		total := len(snapshot.events)
+		if offset >= total {
+			return []*model.Event{}, total
+		}
+		return cloneAll(snapshot.events[offset:min(offset+limit, total)]), total
+	}
 	s.mu.RLock()
 	defer s.mu.RUnlock()
-	events := make([]*model.Event, 0, len(s.events))
+	total := len(s.order)
+	if offset >= total {
+		return []*model.Event{}, total
+	}
+	return s.listRange(offset, min(offset+limit, total)), total
+}
+
+// listRange returns copies of the events at positions [from, to) of the
+// list order. Caller must hold s.mu.
+func (s *Store) listRange(from, to int) []*model.Event {
+	events := make([]*model.Event, 0, to-from)
+	for i := from; i < to; i++ {
+		events = append(events, clone(s.events[s.orderAt(i)]))
+	}
+	return events
+}
+
+// orderAt returns the ID of the event at position i of the list order.
+// Caller must hold s.mu.
+func (s *Store) orderAt(i int) string {
+	if s.listOrder == NewestFirst {
+		return s.order[len(s.order)-1-i]
+	}
+	return s.order[i]
+}
+
+// ListByStatus returns copies of the events with the given status in the
+// configured list order
+func (s *Store) ListByStatus(status model.EventStatus) []*model.Event {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	events := make([]*model.Event, 0)
+	for i := range s.order {
+		if event := s.events[s.orderAt(i)]; event.Status == status {
+			events = append(events, clone(event))
+		}
+	}
+	return events
+}
+
+// Counts returns the number of stored events per status. Statuses without
+// events are absent.
+func (s *Store) Counts() map[model.EventStatus]int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	counts := make(map[model.EventStatus]int)
 	for _, event := range s.events {
-		events = append(events, event)
+		counts[event.Status]++
+	}
+	return counts
+}
+
+// ListUnprocessed returns all events that have been accepted (or scheduled)
+// but not yet processed, in the order they were stored, so recovery
+// re-enqueues them in their original order
+func (s *Store) ListUnprocessed() []*model.Event {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	events := make([]*model.Event, 0)
+	for _, id := range s.order {
+		if event := s.events[id]; event.Status == model.StatusAccepted || event.Status == model.StatusScheduled {
+			events = append(events, event)
+		}
 	}
 	return events
 }
+
+// ListByCorrelationID returns copies of all events sharing the correlation
+// ID, in the order they were stored
+func (s *Store) ListByCorrelationID(correlationID string) []*model.Event {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	ids := s.byCorrelation[correlationID]
+	events := make([]*model.Event, 0, len(ids))
+	for _, id := range ids {
+		events = append(events, clone(s.events[id]))
+	}
+	return events
+}
+
+// ListModifiedAfter returns copies of the events changed after the given
+// update sequence, oldest change first, along with the current sequence to
+// use as the next cursor
+func (s *Store) ListModifiedAfter(seq uint64) ([]*model.Event, uint64) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	start := sort.Search(len(s.changes), func(i int) bool {
+		return s.changes[i].seq > seq
+	})
+	events := make([]*model.Event, 0)
+	for _, c := range s.changes[start:] {
+		event, exists := s.events[c.eventID]
+		// Skip entries superseded by a later change to the same event
+		if exists && event.UpdatedSeq == c.seq {
+			events = append(events, clone(event))
+		}
+	}
+	return events, s.seq
+}
+
+// insert adds or replaces an event and keeps the indexes up to date.
+// Caller must hold s.mu.
+func (s *Store) insert(event *model.Event) {
+	if old, exists := s.events[event.EventID]; exists {
+		s.unindexCorrelation(old)
+		s.unindexFields(old)
+		if s.byDedupKey[old.DedupKey] == old.EventID {
+			delete(s.byDedupKey, old.DedupKey)
+		}
+	} else {
+		// A replaced event keeps its original position
+		s.order = append(s.order, event.EventID)
+	}
+	s.events[event.EventID] = event
+	s.index(event)
+	s.touch(event)
+	s.record(event, model.HistoryEntry{Type: model.HistoryAccepted})
+	event.Transitions = append(event.Transitions, model.StatusTransition{To: event.Status, At: time.Now()})
+	s.evictOverCap()
+}
+
+// index adds the event to the correlation, dedup key and payload field
+// indexes. Caller must hold s.mu.
+func (s *Store) index(event *model.Event) {
+	if event.CorrelationID != "" {
+		s.byCorrelation[event.CorrelationID] = append(s.byCorrelation[event.CorrelationID], event.EventID)
+	}
+	if event.DedupKey != "" {
+		s.byDedupKey[event.DedupKey] = event.EventID
+	}
+	s.indexFields(event)
+}
+
+// remove drops the event and its index entries. Caller must hold s.mu.
+func (s *Store) remove(event *model.Event) {
+	delete(s.events, event.EventID)
+	s.accessed.forget(event.EventID)
+	for i, id := range s.order {
+		if id == event.EventID {
+			s.order = append(s.order[:i], s.order[i+1:]...)
+			break
+		}
+	}
+	if event.CorrelationID != "" {
+		s.unindexCorrelation(event)
+	}
+	if s.byDedupKey[event.DedupKey] == event.EventID {
+		delete(s.byDedupKey, event.DedupKey)
+	}
+	s.unindexFields(event)
+}
+
+// unindexCorrelation removes the event from the correlation index.
+// Caller must hold s.mu.
+func (s *Store) unindexCorrelation(event *model.Event) {
+	ids := s.byCorrelation[event.CorrelationID]
+	for i, id := range ids {
+		if id == event.EventID {
+			ids = append(ids[:i], ids[i+1:]...)
+			break
+		}
+	}
+	if len(ids) == 0 {
+		delete(s.byCorrelation, event.CorrelationID)
+	} else {
+		s.byCorrelation[event.CorrelationID] = ids
+	}
+}
+
+// transition changes the event's status, recording the change. Caller must
+// hold s.mu.
+func (s *Store) transition(event *model.Event, to model.EventStatus, at time.Time) {
+	event.Transitions = append(event.Transitions, model.StatusTransition{From: event.Status, To: to, At: at})
+	event.Status = to
+}
+
+// record timestamps and appends a history entry. Caller must hold s.mu.
+func (s *Store) record(event *model.Event, entry model.HistoryEntry) {
+	if entry.At.IsZero() {
+		entry.At = time.Now()
+	}
+	event.History = append(event.History, entry)
+}
+
+// Version returns the last update sequence and when it was assigned, which
+// together identify the current contents of List. With read snapshots
+// enabled they describe the snapshot List serves.
+func (s *Store) Version() (uint64, time.Time) {
+	if snapshot := s.snapshot.Load(); snapshot != nil {
+		return snapshot.seq, snapshot.modifiedAt
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.seq, s.modifiedAt
+}
+
+// touch assigns the next update sequence to the event. Caller must hold s.mu.
+func (s *Store) touch(event *model.Event) {
+	s.seq++
+	s.modifiedAt = time.Now()
+	event.UpdatedSeq = s.seq
+	s.accessed.access(event.EventID)
+	s.changes = append(s.changes, change{seq: s.seq, eventID: event.EventID})
+
+	// Compact superseded entries once they make up most of the log
+	if len(s.changes) > 2*len(s.events)+64 {
+		live := s.changes[:0]
+		for _, c := range s.changes {
+			if e, exists := s.events[c.eventID]; exists && e.UpdatedSeq == c.seq {
+				live = append(live, c)
+			}
+		}
+		s.changes = live
+	}
+}
@@ -0,0 +1,519 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"event-service/internal/model"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestListModifiedAfter(t *testing.T) {
+	s := New()
+	s.Save(&model.Event{EventID: "a", Status: model.StatusAccepted})
+	s.Save(&model.Event{EventID: "b", Status: model.StatusAccepted})
+
+	events, cursor := s.ListModifiedAfter(0)
+	if len(events) != 2 || cursor != 2 {
+		t.Fatalf("Expected 2 events and cursor 2, got %d events and cursor %d", len(events), cursor)
+	}
+
+	s.MarkProcessed("a")
+
+	events, cursor = s.ListModifiedAfter(cursor)
+	if len(events) != 1 || events[0].EventID != "a" {
+		t.Fatalf("Expected only event a after cursor, got %d events", len(events))
+	}
+	if cursor != 3 {
+		t.Errorf("Expected cursor 3, got %d", cursor)
+	}
+
+	events, _ = s.ListModifiedAfter(cursor)
+	if len(events) != 0 {
+		t.Errorf("Expected no events after latest cursor, got %d", len(events))
+	}
+}
+
+func TestListByCorrelationID(t *testing.T) {
+	s := New()
+	s.Save(&model.Event{EventID: "order_created", CorrelationID: "flow_1"})
+	s.Save(&model.Event{EventID: "other", CorrelationID: "flow_2"})
+	s.Save(&model.Event{EventID: "order_paid", CorrelationID: "flow_1", CausationID: "order_created"})
+
+	events := s.ListByCorrelationID("flow_1")
+	if len(events) != 2 {
+		t.Fatalf("Expected 2 events in flow_1, got %d", len(events))
+	}
+	if events[0].EventID != "order_created" || events[1].EventID != "order_paid" {
+		t.Errorf("Expected flow_1 events in insertion order, got %s, %s", events[0].EventID, events[1].EventID)
+	}
+
+	if events := s.ListByCorrelationID("unknown"); len(events) != 0 {
+		t.Errorf("Expected no events for unknown correlation_id, got %d", len(events))
+	}
+}
+
+func TestReadSnapshots(t *testing.T) {
+	s := New()
+	s.Save(&model.Event{EventID: "a", Status: model.StatusAccepted})
+	s.EnableReadSnapshots(time.Hour)
+	defer s.Close()
+
+	s.Save(&model.Event{EventID: "b", Status: model.StatusAccepted})
+	if err := s.MarkProcessed("a"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	events := s.List()
+	if len(events) != 1 {
+		t.Fatalf("Expected 1 event before refresh, got %d", len(events))
+	}
+	if events[0].Status != model.StatusAccepted {
+		t.Errorf("Expected snapshot to keep status %s, got %s", model.StatusAccepted, events[0].Status)
+	}
+
+	s.refreshSnapshot()
+	if events := s.List(); len(events) != 2 {
+		t.Errorf("Expected 2 events after refresh, got %d", len(events))
+	}
+
+	// Changing a listed slice or event must not reach the snapshot
+	events = s.List()
+	events[0] = &model.Event{EventID: "x"}
+	events[1].Status = model.StatusFailed
+	page, _ := s.ListPaged(1, 0)
+	page[0] = &model.Event{EventID: "y"}
+	if events := s.List(); events[0].EventID != "a" || events[1].Status != model.StatusAccepted {
+		t.Errorf("Expected the snapshot to be unchanged, got %+v", events)
+	}
+}
+
+func TestGet(t *testing.T) {
+	s := New()
+	s.Save(&model.Event{EventID: "a", Type: "order", Payload: json.RawMessage(`{"n":1}`), Status: model.StatusAccepted})
+
+	event, exists := s.Get("a")
+	if !exists {
+		t.Fatal("Expected event a to exist")
+	}
+	if event.EventID != "a" || event.Type != "order" || string(event.Payload) != `{"n":1}` || event.Status != model.StatusAccepted {
+		t.Errorf("Expected the stored event, got %+v", event)
+	}
+
+	// The result is a copy: later updates don't show through it and
+	// changing it doesn't touch the store
+	s.MarkProcessed("a")
+	if event.Status != model.StatusAccepted {
+		t.Errorf("Expected the copy to keep status %s, got %s", model.StatusAccepted, event.Status)
+	}
+	event.Payload[0] = '['
+	event.History[0].Type = model.HistoryProcessed
+	stored, _ := s.Get("a")
+	if string(stored.Payload) != `{"n":1}` || stored.History[0].Type != model.HistoryAccepted {
+		t.Errorf("Expected the stored event to be unaffected, got %+v", stored)
+	}
+
+	if _, exists := s.Get("missing"); exists {
+		t.Error("Expected missing event not to exist")
+	}
+}
+
+func TestVersion(t *testing.T) {
+	s := New()
+	if seq, modifiedAt := s.Version(); seq != 0 || !modifiedAt.IsZero() {
+		t.Errorf("Expected empty store at version 0, got %d at %v", seq, modifiedAt)
+	}
+
+	s.Save(&model.Event{EventID: "a", Status: model.StatusAccepted})
+	first, _ := s.Version()
+	if err := s.MarkProcessed("a"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if second, _ := s.Version(); second <= first {
+		t.Errorf("Expected version to advance past %d, got %d", first, second)
+	}
+}
+
+func TestListPaged(t *testing.T) {
+	s := New()
+	for _, id := range []string{"a", "b", "c", "d", "e"} {
+		s.Save(&model.Event{EventID: id})
+	}
+
+	events, total := s.ListPaged(2, 1)
+	if total != 5 {
+		t.Errorf("Expected total 5, got %d", total)
+	}
+	if len(events) != 2 || events[0].EventID != "b" || events[1].EventID != "c" {
+		t.Fatalf("Expected page [b c], got %d events", len(events))
+	}
+
+	if events, _ := s.ListPaged(10, 4); len(events) != 1 || events[0].EventID != "e" {
+		t.Errorf("Expected a short last page with e, got %d events", len(events))
+	}
+	if events, total := s.ListPaged(10, 10); len(events) != 0 || total != 5 {
+		t.Errorf("Expected an empty page past the end with total 5, got %d events, total %d", len(events), total)
+	}
+}
+
+func TestListKeepsInsertionOrder(t *testing.T) {
+	for _, tc := range []struct {
+		order    ListOrder
+		expected []string
+	}{
+		{OldestFirst, []string{"A", "B", "C"}},
+		{NewestFirst, []string{"C", "B", "A"}},
+	} {
+		s := New()
+		s.SetListOrder(tc.order)
+		for _, id := range []string{"A", "B", "C"} {
+			s.Save(&model.Event{EventID: id})
+		}
+		// Updating an event must not move it
+		s.MarkProcessed("A")
+
+		for i := 0; i < 10; i++ {
+			events := s.List()
+			ids := make([]string, len(events))
+			for j, event := range events {
+				ids[j] = event.EventID
+			}
+			if !reflect.DeepEqual(ids, tc.expected) {
+				t.Fatalf("%s: expected %v, got %v", tc.order, tc.expected, ids)
+			}
+		}
+
+		if page, _ := s.ListPaged(1, 1); page[0].EventID != "B" {
+			t.Errorf("%s: expected B in the middle, got %s", tc.order, page[0].EventID)
+		}
+	}
+}
+
+func TestListUnprocessedKeepsInsertionOrder(t *testing.T) {
+	s := New()
+	// Recovery replays in insertion order whatever the list order
+	s.SetListOrder(NewestFirst)
+	var expected []string
+	for i := 0; i < 50; i++ {
+		id := fmt.Sprintf("evt_%02d", i)
+		s.Save(&model.Event{EventID: id, Status: model.StatusAccepted})
+		if i%3 == 0 {
+			s.MarkProcessed(id)
+			continue
+		}
+		expected = append(expected, id)
+	}
+
+	events := s.ListUnprocessed()
+	ids := make([]string, len(events))
+	for i, event := range events {
+		ids[i] = event.EventID
+	}
+	if !reflect.DeepEqual(ids, expected) {
+		t.Errorf("Expected %v, got %v", expected, ids)
+	}
+}
+
+func TestMarkProcessedSetsProcessedAt(t *testing.T) {
+	s := New()
+	createdAt := time.Now()
+	s.Save(&model.Event{EventID: "a", Status: model.StatusAccepted, CreatedAt: createdAt})
+
+	if event, _ := s.Get("a"); event.ProcessedAt != nil {
+		t.Fatal("Expected no processed_at before processing")
+	}
+	s.MarkProcessed("a")
+
+	event, _ := s.Get("a")
+	if event.ProcessedAt == nil || event.ProcessedAt.Before(createdAt) {
+		t.Fatalf("Expected processed_at after created_at, got %v", event.ProcessedAt)
+	}
+	if !event.CreatedAt.Equal(createdAt) {
+		t.Errorf("Expected created_at to be kept, got %v", event.CreatedAt)
+	}
+}
+
+func TestStatusTransitions(t *testing.T) {
+	s := New()
+	s.Save(&model.Event{EventID: "a", Status: model.StatusScheduled})
+	s.MarkDue("a")
+	s.MarkFailed("a")
+	s.MarkFailed("a")
+
+	event, _ := s.Get("a")
+	expected := []model.StatusTransition{
+		{To: model.StatusScheduled},
+		{From: model.StatusScheduled, To: model.StatusAccepted},
+		{From: model.StatusAccepted, To: model.StatusFailed},
+	}
+	if len(event.Transitions) != len(expected) {
+		t.Fatalf("Expected %d transitions, got %+v", len(expected), event.Transitions)
+	}
+	for i, transition := range event.Transitions {
+		if transition.From != expected[i].From || transition.To != expected[i].To {
+			t.Errorf("Expected transition %d %s->%s, got %s->%s", i, expected[i].From, expected[i].To, transition.From, transition.To)
+		}
+	}
+
+	// The copy returned by Get doesn't share the live transitions
+	event.Transitions[0].To = model.StatusProcessed
+	if stored, _ := s.Get("a"); stored.Transitions[0].To != model.StatusScheduled {
+		t.Error("Expected changes to the copy not to reach the store")
+	}
+}
+
+func TestDelete(t *testing.T) {
+	s := New()
+	s.Save(&model.Event{EventID: "a", CorrelationID: "flow", DedupKey: "k"})
+	s.Save(&model.Event{EventID: "b", CorrelationID: "flow"})
+	seq, _ := s.Version()
+
+	if deleted, err := s.Delete("a"); !deleted || err != nil {
+		t.Fatalf("Expected a to be deleted, got %v, %v", deleted, err)
+	}
+	if deleted, _ := s.Delete("a"); deleted {
+		t.Error("Expected a second delete to report a missing event")
+	}
+	if _, exists := s.Get("a"); exists {
+		t.Error("Expected a to be gone")
+	}
+	if events := s.List(); len(events) != 1 || events[0].EventID != "b" {
+		t.Errorf("Expected only b to be listed, got %v", events)
+	}
+	if events := s.ListByCorrelationID("flow"); len(events) != 1 {
+		t.Errorf("Expected a to leave the correlation index, got %d events", len(events))
+	}
+	if after, _ := s.Version(); after == seq {
+		t.Error("Expected the delete to change the store version")
+	}
+	// The dedup key is free again
+	if saved, _ := s.SaveIfAbsent(&model.Event{EventID: "c", DedupKey: "k"}); !saved {
+		t.Error("Expected the dedup key of a deleted event to be reusable")
+	}
+}
+
+func TestSQLiteBackendSurvivesRestart(t *testing.T) {
+	dsn := filepath.Join(t.TempDir(), "events.db")
+
+	open := func() *Store {
+		backend, err := OpenSQLite(dsn)
+		if err != nil {
+			t.Fatalf("Failed to open SQLite: %v", err)
+		}
+		s := New()
+		if err := s.UseBackend(backend); err != nil {
+			t.Fatalf("Failed to load events: %v", err)
+		}
+		return s
+	}
+
+	s := open()
+	for _, id := range []string{"a", "b", "c"} {
+		if saved, err := s.SaveIfAbsent(&model.Event{EventID: id, Payload: json.RawMessage(`{"n": 1}`), Status: model.StatusAccepted}); !saved || err != nil {
+			t.Fatalf("Expected %s to be saved, got %t %v", id, saved, err)
+		}
+	}
+	s.MarkProcessed("b")
+	s.Delete("c")
+	s.Close()
+
+	// A fresh store on the same database
+	s = open()
+	defer s.Close()
+	if saved, _ := s.SaveIfAbsent(&model.Event{EventID: "a"}); saved {
+		t.Error("Expected a to still be a duplicate after the restart")
+	}
+	if status, _ := s.GetStatus("b"); status != model.StatusProcessed {
+		t.Errorf("Expected b to stay processed, got %s", status)
+	}
+	if _, exists := s.Get("c"); exists {
+		t.Error("Expected deleted c to stay deleted")
+	}
+	events := s.List()
+	if len(events) != 2 || events[0].EventID != "a" || string(events[0].Payload) != `{"n":1}` {
+		t.Errorf("Expected a then b with their payloads, got %+v", events)
+	}
+	if unprocessed := s.ListUnprocessed(); len(unprocessed) != 1 || unprocessed[0].EventID != "a" {
+		t.Errorf("Expected a to be recoverable, got %+v", unprocessed)
+	}
+}
+
+func TestWritesAfterCloseFail(t *testing.T) {
+	backend, err := OpenSQLite(filepath.Join(t.TempDir(), "events.db"))
+	if err != nil {
+		t.Fatalf("Failed to open SQLite: %v", err)
+	}
+	s := New()
+	if err := s.UseBackend(backend); err != nil {
+		t.Fatalf("Failed to load events: %v", err)
+	}
+	s.Save(&model.Event{EventID: "a", Status: model.StatusAccepted})
+	s.Save(&model.Event{EventID: "b", Status: model.StatusAccepted})
+	s.Close()
+
+	if err := s.MarkProcessed("a"); !errors.Is(err, ErrPersistence) {
+		t.Errorf("Expected an update after Close to fail to persist, got %v", err)
+	}
+	if deleted, err := s.Delete("b"); !deleted || !errors.Is(err, ErrPersistence) {
+		t.Errorf("Expected a delete after Close to fail to persist, got %v, %v", deleted, err)
+	}
+	if saved, err := s.SaveIfAbsent(&model.Event{EventID: "c"}); saved || !errors.Is(err, ErrPersistence) {
+		t.Errorf("Expected a new event after Close to be rejected, got %v, %v", saved, err)
+	}
+
+	// Without a backend there is nothing to persist
+	memory := New()
+	memory.Close()
+	if saved, err := memory.SaveIfAbsent(&model.Event{EventID: "a"}); !saved || err != nil {
+		t.Errorf("Expected the in-memory store to keep accepting events, got %v, %v", saved, err)
+	}
+}
+
+// slowBackend holds every Save until release is closed, recording the
+// writes in the order they arrive
+type slowBackend struct {
+	started chan struct{}
+	release chan struct{}
+
+	mu     sync.Mutex
+	writes []string
+}
+
+func (b *slowBackend) Save(event *model.Event) error {
+	select {
+	case b.started <- struct{}{}:
+	default:
+	}
+	<-b.release
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.writes = append(b.writes, event.EventID+":"+string(event.Status))
+	return nil
+}
+
+func (b *slowBackend) Delete(eventID string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.writes = append(b.writes, eventID+":deleted")
+	return nil
+}
+
+func (b *slowBackend) List() ([]*model.Event, error) { return nil, nil }
+func (b *slowBackend) Close() error                  { return nil }
+
+func TestBackendWritesDontBlockReads(t *testing.T) {
+	backend := &slowBackend{started: make(chan struct{}, 1), release: make(chan struct{})}
+	s := New()
+	if err := s.UseBackend(backend); err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.Save(&model.Event{EventID: "a", Status: model.StatusAccepted})
+		s.MarkProcessed("a")
+		s.Delete("a")
+	}()
+	<-backend.started
+
+	// The write is under way; reads must not wait for it
+	read := make(chan model.EventStatus)
+	go func() {
+		s.List()
+		status, _ := s.GetStatus("a")
+		read <- status
+	}()
+	select {
+	case status := <-read:
+		if status != model.StatusAccepted {
+			t.Errorf("Expected a to be readable while it is persisted, got %q", status)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected reads not to wait for the backend")
+	}
+
+	close(backend.release)
+	<-done
+	s.Close()
+	backend.mu.Lock()
+	defer backend.mu.Unlock()
+	if expected := []string{"a:accepted", "a:processed", "a:deleted"}; !reflect.DeepEqual(backend.writes, expected) {
+		t.Errorf("Expected writes %v in order, got %v", expected, backend.writes)
+	}
+}
+
+func TestPayloadFieldIndex(t *testing.T) {
+	s := New()
+	s.SetIndexedFields([]string{"user_id"})
+	s.Save(&model.Event{EventID: "a", Payload: json.RawMessage(`{"user_id": 1}`)})
+	s.Save(&model.Event{EventID: "b", Payload: json.RawMessage(`{"user_id": 1}`)})
+	s.Save(&model.Event{EventID: "c", Payload: json.RawMessage(`{"user_id": [1]}`)})
+
+	ids := func(value string) []string {
+		events, err := s.ListByPayloadFields(map[string]string{"user_id": value})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		result := make([]string, 0)
+		for _, event := range events {
+			result = append(result, event.EventID)
+		}
+		return result
+	}
+
+	if got := ids("1"); !reflect.DeepEqual(got, []string{"a", "b"}) {
+		t.Errorf("Expected [a b], got %v", got)
+	}
+	s.SetPayload("a", json.RawMessage(`{"user_id": 2}`))
+	s.Delete("b")
+	if got := ids("1"); len(got) != 0 {
+		t.Errorf("Expected no events left for 1, got %v", got)
+	}
+	if got := ids("2"); !reflect.DeepEqual(got, []string{"a"}) {
+		t.Errorf("Expected [a], got %v", got)
+	}
+	if _, err := s.ListByPayloadFields(map[string]string{"email": "x"}); err != ErrFieldNotIndexed {
+		t.Errorf("Expected ErrFieldNotIndexed, got %v", err)
+	}
+}
+
+func TestCounts(t *testing.T) {
+	s := New()
+	s.Save(&model.Event{EventID: "a", Status: model.StatusAccepted})
+	s.Save(&model.Event{EventID: "b", Status: model.StatusAccepted})
+	s.Save(&model.Event{EventID: "c", Status: model.StatusScheduled})
+	s.MarkProcessed("a")
+
+	expected := map[model.EventStatus]int{model.StatusAccepted: 1, model.StatusScheduled: 1, model.StatusProcessed: 1}
+	if counts := s.Counts(); !reflect.DeepEqual(counts, expected) {
+		t.Errorf("Expected %v, got %v", expected, counts)
+	}
+}
+
+func TestUpdatePayload(t *testing.T) {
+	s := New()
+	s.Save(&model.Event{EventID: "a", Status: model.StatusAccepted, Payload: json.RawMessage(`{"n": 1}`)})
+	s.Save(&model.Event{EventID: "b", Status: model.StatusAccepted, Payload: json.RawMessage(`{"n": 1}`)})
+	s.MarkProcessed("b")
+
+	if updated, err := s.UpdatePayload("a", json.RawMessage(`{"n": 2}`)); !updated || err != nil {
+		t.Fatalf("Expected an accepted event to be updated, got %v, %v", updated, err)
+	}
+	if event, _ := s.Get("a"); string(event.Payload) != `{"n": 2}` {
+		t.Errorf("Expected the new payload, got %s", event.Payload)
+	}
+	if updated, err := s.UpdatePayload("b", json.RawMessage(`{"n": 2}`)); updated || err != nil {
+		t.Errorf("Expected a processed event to be left alone, got %v, %v", updated, err)
+	}
+	if event, _ := s.Get("b"); string(event.Payload) != `{"n": 1}` {
+		t.Errorf("Expected the processed payload to be unchanged, got %s", event.Payload)
+	}
+	if _, err := s.UpdatePayload("missing", json.RawMessage(`{}`)); err != ErrNotFound {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,93 @@
+package store
+
+import (
+	"event-service/internal/model"
+	"log"
+	"time"
+)
+
+// maxSweepInterval bounds how long an expired event may linger before the
+// sweeper evicts it, however long the TTL
+const maxSweepInterval = time.Minute
+
+// EnableIdempotencyTTL lets an event ID be accepted again once the event
+// holding it was accepted more than ttl ago. Only processed and failed
+// events expire: an event still queued or being processed is never evicted
+// from under the worker. Expired events count as absent right away and are
+// evicted by a background sweep, or when their ID or dedup key is reused.
+//
+// It must be called before the store is used. Call Close to stop the sweep.
+func (s *Store) EnableIdempotencyTTL(ttl time.Duration) {
+	if ttl <= 0 {
+		return
+	}
+	s.ttl = ttl
+
+	stop := make(chan struct{})
+	s.mu.Lock()
+	s.stopSweeper = stop
+	s.mu.Unlock()
+
+	go func() {
+		ticker := time.NewTicker(min(ttl, maxSweepInterval))
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if evicted := s.sweepExpired(); evicted > 0 {
+					log.Printf("Evicted %d events older than the idempotency TTL", evicted)
+				}
+			case <-stop:
+				return
+			}
+		}
+	}()
+	log.Printf("Event IDs can be reused %s after acceptance", ttl)
+}
+
+// expired reports whether a finished event is older than the TTL. Events
+// without an acceptance time never expire. Caller must hold s.mu.
+func (s *Store) expired(event *model.Event, now time.Time) bool {
+	if s.ttl <= 0 || event.CreatedAt.IsZero() {
+		return false
+	}
+	return finished(event) && now.Sub(event.CreatedAt) > s.ttl
+}
+
+// finished reports whether the worker is done with the event
+func finished(event *model.Event) bool {
+	return event.Status == model.StatusProcessed || event.Status == model.StatusFailed
+}
+
+// live returns the stored event with the given ID, evicting it instead if
+// it has expired. Caller must hold s.mu for writing and flush after
+// releasing it.
+func (s *Store) live(eventID string) (*model.Event, bool) {
+	event, exists := s.events[eventID]
+	if !exists {
+		return nil, false
+	}
+	if s.expired(event, time.Now()) {
+		s.drop(event)
+		return nil, false
+	}
+	return event, true
+}
+
+// sweepExpired evicts every expired event and returns how many it evicted
+func (s *Store) sweepExpired() int {
+	defer s.flush(nil)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	var expired []*model.Event
+	for _, event := range s.events {
+		if s.expired(event, now) {
+			expired = append(expired, event)
+		}
+	}
+	for _, event := range expired {
+		s.drop(event)
+	}
+	return len(expired)
+}
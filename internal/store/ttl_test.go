@@ -0,0 +1,55 @@
+package store
+
+import (
+	"event-service/internal/model"
+	"testing"
+	"time"
+)
+
+func TestIdempotencyTTL(t *testing.T) {
+	s := New()
+	s.EnableIdempotencyTTL(20 * time.Millisecond)
+	defer s.Close()
+
+	old := time.Now().Add(-time.Hour)
+	s.Save(&model.Event{EventID: "done", Status: model.StatusAccepted, CreatedAt: old, DedupKey: "k"})
+	s.MarkProcessed("done")
+	s.Save(&model.Event{EventID: "pending", Status: model.StatusAccepted, CreatedAt: old})
+
+	if s.Exists("done") {
+		t.Error("Expected an expired event to count as absent")
+	}
+	if !s.Exists("pending") {
+		t.Error("Expected an unprocessed event never to expire")
+	}
+
+	// The expired ID and dedup key can be accepted again
+	saved, err := s.SaveIfAbsent(&model.Event{EventID: "done", Status: model.StatusAccepted, CreatedAt: time.Now(), DedupKey: "k"})
+	if !saved || err != nil {
+		t.Fatalf("Expected the expired ID to be re-accepted, got %v, %v", saved, err)
+	}
+	if status, _ := s.GetStatus("done"); status != model.StatusAccepted {
+		t.Errorf("Expected the new event to replace the expired one, got %s", status)
+	}
+	if saved, _ := s.SaveIfAbsent(&model.Event{EventID: "done", Status: model.StatusAccepted, CreatedAt: time.Now()}); saved {
+		t.Error("Expected the fresh event to still be a duplicate")
+	}
+}
+
+func TestIdempotencyTTLSweep(t *testing.T) {
+	s := New()
+	s.EnableIdempotencyTTL(10 * time.Millisecond)
+	defer s.Close()
+
+	s.Save(&model.Event{EventID: "a", Status: model.StatusAccepted, CreatedAt: time.Now()})
+	s.MarkProcessed("a")
+
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		if _, exists := s.GetStatus("a"); !exists {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Error("Expected the sweeper to evict the expired event")
+}
@@ -0,0 +1,133 @@
+package worker
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"event-service/internal/model"
+	"event-service/internal/store"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Defaults for CallbackConfig fields left zero
+const (
+	DefaultCallbackTimeout     = 5 * time.Second
+	DefaultCallbackMaxAttempts = 5
+	DefaultCallbackBackoff     = time.Second
+)
+
+// CallbackConfig configures delivery to events' callback URLs
+type CallbackConfig struct {
+	// Timeout bounds each delivery attempt
+	Timeout time.Duration
+	// MaxAttempts is how often delivery is tried before giving up; retries
+	// back off exponentially from Backoff
+	MaxAttempts int
+	Backoff     time.Duration
+}
+
+// notifier delivers the final state of events to their callback URLs in
+// the background, so a slow receiver never holds up processing
+type notifier struct {
+	config  CallbackConfig
+	client  *http.Client
+	store   *store.Store
+	pending sync.WaitGroup
+}
+
+func newNotifier(st *store.Store, config CallbackConfig) *notifier {
+	if config.Timeout <= 0 {
+		config.Timeout = DefaultCallbackTimeout
+	}
+	if config.MaxAttempts < 1 {
+		config.MaxAttempts = DefaultCallbackMaxAttempts
+	}
+	if config.Backoff <= 0 {
+		config.Backoff = DefaultCallbackBackoff
+	}
+	return &notifier{config: config, client: &http.Client{Timeout: config.Timeout}, store: st}
+}
+
+// notify sends the event as stored now to its callback URL, if it has one.
+// It is called once the event reached its final status.
+func (n *notifier) notify(event *model.Event) {
+	if event.CallbackURL == "" {
+		return
+	}
+	stored, exists := n.store.Get(event.EventID)
+	if !exists {
+		return
+	}
+	body, err := json.Marshal(model.EventCallback{
+		EventID:       stored.EventID,
+		Type:          stored.Type,
+		Status:        stored.Status,
+		Payload:       stored.Payload,
+		CorrelationID: stored.CorrelationID,
+		CausationID:   stored.CausationID,
+		Attempts:      stored.Attempts,
+		ProcessedAt:   stored.ProcessedAt,
+	})
+	if err != nil {
+		logEvent(event, "Failed to encode callback for event %s: %v", event.EventID, err)
+		return
+	}
+
+	n.pending.Add(1)
+	go func() {
+		defer n.pending.Done()
+		for attempt := 1; ; attempt++ {
+			err := n.deliver(stored.CallbackURL, body)
+			if err == nil {
+				logEvent(event, "Delivered callback for event %s", event.EventID)
+				return
+			}
+			if attempt >= n.config.MaxAttempts {
+				logEvent(event, "ERROR: callback for event %s failed after %d attempts, giving up: %v", event.EventID, attempt, err)
+				return
+			}
+			delay := retryDelay(attempt, n.config.Backoff, 0)
+			logEvent(event, "Callback for event %s failed (attempt %d), retrying in %s: %v", event.EventID, attempt, delay, err)
+			time.Sleep(delay)
+		}
+	}()
+}
+
+// deliver makes one delivery attempt; any status other than 2xx fails it
+func (n *notifier) deliver(callbackURL string, body []byte) error {
+	req, err := http.NewRequest(http.MethodPost, callbackURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("callback returned %s", resp.Status)
+	}
+	return nil
+}
+
+// wait blocks until every callback under way was delivered or given up on,
+// or ctx is done. It returns false in the latter case.
+func (n *notifier) wait(ctx context.Context) bool {
+	done := make(chan struct{})
+	go func() {
+		n.pending.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,83 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"event-service/internal/model"
+	"event-service/internal/store"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCallbackDeliveredAfterProcessing(t *testing.T) {
+	var calls int32
+	received := make(chan model.EventCallback, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			// The first delivery fails and must be retried
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		var callback model.EventCallback
+		if err := json.NewDecoder(r.Body).Decode(&callback); err != nil {
+			t.Errorf("Failed to decode callback: %v", err)
+		}
+		received <- callback
+	}))
+	defer server.Close()
+
+	st := store.New()
+	w := New(st, Config{Mode: ModeManual, Callbacks: CallbackConfig{Timeout: time.Second, MaxAttempts: 3, Backoff: time.Millisecond}})
+	w.Start()
+	defer w.Stop(context.Background())
+
+	event := &model.Event{EventID: "evt_1", Status: model.StatusAccepted, Payload: json.RawMessage(`{"amount":1}`), CallbackURL: server.URL}
+	st.Save(event)
+	w.Enqueue(event)
+	w.Tick(1)
+
+	select {
+	case callback := <-received:
+		if callback.EventID != "evt_1" || callback.Status != model.StatusProcessed {
+			t.Errorf("Expected evt_1 processed, got %s %s", callback.EventID, callback.Status)
+		}
+		if string(callback.Payload) != `{"amount":1}` {
+			t.Errorf("Expected the payload, got %s", callback.Payload)
+		}
+		if callback.ProcessedAt == nil {
+			t.Error("Expected processed_at to be set")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Timed out waiting for the callback")
+	}
+	if calls != 2 {
+		t.Errorf("Expected 2 deliveries, got %d", calls)
+	}
+}
+
+func TestCallbackGivesUpAfterMaxAttempts(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	st := store.New()
+	n := newNotifier(st, CallbackConfig{Timeout: time.Second, MaxAttempts: 3, Backoff: time.Millisecond})
+	event := &model.Event{EventID: "evt_1", Status: model.StatusFailed, CallbackURL: server.URL}
+	st.Save(event)
+	n.notify(event)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if !n.wait(ctx) {
+		t.Fatal("Timed out waiting for delivery to give up")
+	}
+	if calls != 3 {
+		t.Errorf("Expected 3 attempts, got %d", calls)
+	}
+}
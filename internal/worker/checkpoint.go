@@ -0,0 +1,46 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"event-service/internal/model"
+	"event-service/internal/store"
+)
+
+// ErrInvalidCheckpoint is returned by SaveCheckpoint for data that is not valid JSON
+var ErrInvalidCheckpoint = errors.New("checkpoint is not valid JSON")
+
+type checkpointKey struct{}
+
+// checkpointFunc persists progress for the event being processed
+type checkpointFunc func(data json.RawMessage) error
+
+// CheckpointMiddleware lets processing further down the chain persist its
+// progress with SaveCheckpoint. The checkpoint is kept on the stored event,
+// so an event recovered after a crash is re-enqueued with it and the
+// processor can resume from event.Checkpoint instead of starting over.
+func CheckpointMiddleware(st *store.Store) Middleware {
+	return func(next ProcessFunc) ProcessFunc {
+		return func(ctx context.Context, event *model.Event) error {
+			save := checkpointFunc(func(data json.RawMessage) error {
+				return st.SaveCheckpoint(event.EventID, data)
+			})
+			return next(context.WithValue(ctx, checkpointKey{}, save), event)
+		}
+	}
+}
+
+// SaveCheckpoint persists progress for the event being processed, e.g.
+// {"processed":500,"total":1000}, replacing the previous checkpoint.
+// It does nothing when checkpoints are disabled.
+func SaveCheckpoint(ctx context.Context, data json.RawMessage) error {
+	save, ok := ctx.Value(checkpointKey{}).(checkpointFunc)
+	if !ok {
+		return nil
+	}
+	if !json.Valid(data) {
+		return ErrInvalidCheckpoint
+	}
+	return save(data)
+}
@@ -0,0 +1,61 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"event-service/internal/model"
+	"event-service/internal/store"
+	"testing"
+)
+
+func TestCheckpointSurvivesRecovery(t *testing.T) {
+	st := store.New()
+	w := New(st, Config{Mode: ModeManual, Checkpoints: true})
+	w.Use(func(next ProcessFunc) ProcessFunc {
+		return func(ctx context.Context, event *model.Event) error {
+			if err := SaveCheckpoint(ctx, json.RawMessage(`{"processed":500}`)); err != nil {
+				return err
+			}
+			return errors.New("crashed")
+		}
+	})
+
+	event := &model.Event{EventID: "evt_1", Status: model.StatusAccepted}
+	st.Save(event)
+	// Run the chain alone, as if the instance died before recording the outcome
+	w.process(context.Background(), event)
+
+	// A fresh worker recovers the event with its checkpoint
+	next := New(st, Config{Mode: ModeManual, Checkpoints: true})
+	var resumedFrom string
+	next.Use(func(_ ProcessFunc) ProcessFunc {
+		return func(ctx context.Context, event *model.Event) error {
+			resumedFrom = string(event.Checkpoint)
+			return nil
+		}
+	})
+	if recovered := next.Recover(); recovered != 1 {
+		t.Fatalf("Expected 1 recovered event, got %d", recovered)
+	}
+	next.Tick(1)
+
+	if resumedFrom != `{"processed":500}` {
+		t.Errorf("Expected processing to resume from the checkpoint, got %q", resumedFrom)
+	}
+}
+
+func TestSaveCheckpointDisabledOrInvalid(t *testing.T) {
+	if err := SaveCheckpoint(context.Background(), json.RawMessage(`{}`)); err != nil {
+		t.Errorf("Expected no-op without checkpoints enabled, got %v", err)
+	}
+
+	st := store.New()
+	st.Save(&model.Event{EventID: "evt_1"})
+	process := CheckpointMiddleware(st)(func(ctx context.Context, event *model.Event) error {
+		return SaveCheckpoint(ctx, json.RawMessage(`{not json`))
+	})
+	if err := process(context.Background(), &model.Event{EventID: "evt_1"}); !errors.Is(err, ErrInvalidCheckpoint) {
+		t.Errorf("Expected ErrInvalidCheckpoint, got %v", err)
+	}
+}
@@ -0,0 +1,88 @@
+package worker
+
+import (
+	"event-service/internal/model"
+	"sync"
+)
+
+// orderedCommits makes the commit step of processed events (marking them
+// processed) happen in the order the events were queued, while processing
+// itself still runs in parallel. An event that finishes before its
+// predecessors is buffered and committed by whichever goroutine commits the
+// last of them, so commit never blocks the caller.
+//
+// A retried event keeps its place, so the events queued after it wait for
+// the retry. A nil *orderedCommits commits everything immediately.
+type orderedCommits struct {
+	mu       sync.Mutex
+	seqs     map[*model.Event]uint64
+	next     uint64 // sequence of the next queued event
+	turn     uint64 // sequence allowed to commit next
+	ready    map[uint64]func()
+	draining bool
+}
+
+func newOrderedCommits() *orderedCommits {
+	return &orderedCommits{
+		seqs:  make(map[*model.Event]uint64),
+		ready: make(map[uint64]func()),
+	}
+}
+
+// assign gives a newly queued event its place in the commit order. An event
+// re-queued for a retry keeps the place it already has.
+func (c *orderedCommits) assign(event *model.Event) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.seqs[event]; !ok {
+		c.seqs[event] = c.next
+		c.next++
+	}
+}
+
+// commit runs fn once every event queued before this one has committed
+func (c *orderedCommits) commit(event *model.Event, fn func()) {
+	if c == nil {
+		fn()
+		return
+	}
+	c.mu.Lock()
+	seq, ok := c.seqs[event]
+	if !ok {
+		// Not queued through the worker (e.g. processed directly in tests)
+		c.mu.Unlock()
+		fn()
+		return
+	}
+	delete(c.seqs, event)
+	c.ready[seq] = fn
+	if c.draining {
+		c.mu.Unlock()
+		return
+	}
+	c.draining = true
+	for {
+		next, ok := c.ready[c.turn]
+		if !ok {
+			c.draining = false
+			c.mu.Unlock()
+			return
+		}
+		delete(c.ready, c.turn)
+		c.turn++
+		// Commit without the lock so slow store updates don't block assign
+		c.mu.Unlock()
+		next()
+		c.mu.Lock()
+	}
+}
+
+// skip gives up the event's place without committing anything, for events
+// that leave the queue for good without being processed, so their
+// successors aren't held up. If the event comes back it is queued anew.
+func (c *orderedCommits) skip(event *model.Event) {
+	c.commit(event, func() {})
+}
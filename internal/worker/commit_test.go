@@ -0,0 +1,80 @@
+package worker
+
+import (
+	"context"
+	"event-service/internal/model"
+	"event-service/internal/store"
+	"testing"
+	"time"
+)
+
+func TestOrderedCommit(t *testing.T) {
+	st := store.New()
+	w := New(st, Config{Concurrency: 4, OrderedCommit: true})
+	// Earlier events take longer, so they finish processing last
+	delays := map[string]time.Duration{"a": 80 * time.Millisecond, "b": 60 * time.Millisecond, "c": 40 * time.Millisecond, "d": 20 * time.Millisecond}
+	w.Use(func(next ProcessFunc) ProcessFunc {
+		return func(ctx context.Context, event *model.Event) error {
+			time.Sleep(delays[event.EventID])
+			return next(ctx, event)
+		}
+	})
+	w.Start()
+	defer w.Stop(context.Background())
+
+	ids := []string{"a", "b", "c", "d"}
+	for _, id := range ids {
+		event := &model.Event{EventID: id, Status: model.StatusAccepted}
+		st.Save(event)
+		w.Enqueue(event)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if status, _ := st.GetStatus("a"); status == model.StatusProcessed {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	var lastSeq uint64
+	for _, id := range ids {
+		event, _ := st.Get(id)
+		if event.Status != model.StatusProcessed {
+			t.Fatalf("Expected %s to be processed, got %s", id, event.Status)
+		}
+		if event.UpdatedSeq <= lastSeq {
+			t.Errorf("Expected %s to be committed after its predecessors", id)
+		}
+		lastSeq = event.UpdatedSeq
+	}
+}
+
+func TestOrderedCommitSkipsFailedEvents(t *testing.T) {
+	st := store.New()
+	w := New(st, Config{Mode: ModeManual, OrderedCommit: true})
+	w.Use(func(next ProcessFunc) ProcessFunc {
+		return func(ctx context.Context, event *model.Event) error {
+			if event.EventID == "bad" {
+				return context.Canceled
+			}
+			return next(ctx, event)
+		}
+	})
+	w.Start()
+	defer w.Stop(context.Background())
+
+	for _, id := range []string{"bad", "good"} {
+		event := &model.Event{EventID: id, Status: model.StatusAccepted}
+		st.Save(event)
+		w.Enqueue(event)
+	}
+	w.Tick(2)
+
+	if status, _ := st.GetStatus("bad"); status != model.StatusFailed {
+		t.Errorf("Expected bad to fail without retries, got %s", status)
+	}
+	if status, _ := st.GetStatus("good"); status != model.StatusProcessed {
+		t.Errorf("Expected a failed predecessor not to hold up good, got %s", status)
+	}
+}
@@ -0,0 +1,93 @@
+package worker
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultStatsWindow is how many recent processing durations are kept for
+// DurationStats when Config.StatsWindow is not set
+const DefaultStatsWindow = 1000
+
+// durationWindow keeps the processing durations of the most recent attempts
+// in a ring buffer, oldest first
+type durationWindow struct {
+	mu        sync.Mutex
+	durations []time.Duration
+	limit     int
+	start     int // index of the oldest duration once the buffer is full
+}
+
+func newDurationWindow(limit int) *durationWindow {
+	if limit < 1 {
+		limit = DefaultStatsWindow
+	}
+	return &durationWindow{durations: make([]time.Duration, 0, limit), limit: limit}
+}
+
+// record adds the duration of an attempt that just finished
+func (d *durationWindow) record(duration time.Duration) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if len(d.durations) < d.limit {
+		d.durations = append(d.durations, duration)
+		return
+	}
+	d.durations[d.start] = duration
+	d.start = (d.start + 1) % d.limit
+}
+
+// DurationStats summarizes the processing durations of recent attempts.
+// Percentiles use the nearest-rank method; all fields are zero when
+// nothing was processed yet.
+type DurationStats struct {
+	// Window is the most attempts the summary covers; Count how many it does
+	Window int
+	Count  int
+	Min    time.Duration
+	Max    time.Duration
+	Mean   time.Duration
+	P50    time.Duration
+	P95    time.Duration
+	P99    time.Duration
+}
+
+// summary computes the stats over the durations currently kept
+func (d *durationWindow) summary() DurationStats {
+	d.mu.Lock()
+	sorted := append([]time.Duration(nil), d.durations...)
+	d.mu.Unlock()
+
+	stats := DurationStats{Window: d.limit, Count: len(sorted)}
+	if len(sorted) == 0 {
+		return stats
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	var total time.Duration
+	for _, duration := range sorted {
+		total += duration
+	}
+	stats.Min = sorted[0]
+	stats.Max = sorted[len(sorted)-1]
+	stats.Mean = total / time.Duration(len(sorted))
+	stats.P50 = percentile(sorted, 50)
+	stats.P95 = percentile(sorted, 95)
+	stats.P99 = percentile(sorted, 99)
+	return stats
+}
+
+// percentile returns the nearest-rank percentile p of ascending durations
+func percentile(sorted []time.Duration, p int) time.Duration {
+	rank := (p*len(sorted) + 99) / 100 // ceil(p/100 * n)
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+// DurationStats summarizes how long the most recent processing attempts,
+// successful or not, took to run through the processing chain
+func (w *Worker) DurationStats() DurationStats {
+	return w.stats.durations.summary()
+}
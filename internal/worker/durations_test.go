@@ -0,0 +1,49 @@
+package worker
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDurationWindowPercentiles(t *testing.T) {
+	d := newDurationWindow(100)
+	if stats := d.summary(); stats.Count != 0 || stats.P99 != 0 {
+		t.Errorf("Expected empty stats, got %+v", stats)
+	}
+
+	// Recorded out of order: 100ms down to 1ms
+	for i := 100; i >= 1; i-- {
+		d.record(time.Duration(i) * time.Millisecond)
+	}
+	expected := DurationStats{
+		Window: 100,
+		Count:  100,
+		Min:    time.Millisecond,
+		Max:    100 * time.Millisecond,
+		Mean:   50500 * time.Microsecond,
+		P50:    50 * time.Millisecond,
+		P95:    95 * time.Millisecond,
+		P99:    99 * time.Millisecond,
+	}
+	if stats := d.summary(); stats != expected {
+		t.Errorf("Expected %+v, got %+v", expected, stats)
+	}
+}
+
+func TestDurationWindowIsBounded(t *testing.T) {
+	d := newDurationWindow(3)
+	for _, ms := range []int{500, 400, 1, 2, 3} {
+		d.record(time.Duration(ms) * time.Millisecond)
+	}
+
+	stats := d.summary()
+	if stats.Count != 3 || stats.Max != 3*time.Millisecond || stats.P50 != 2*time.Millisecond {
+		t.Errorf("Expected only the 3 newest durations, got %+v", stats)
+	}
+	// A single duration is every percentile
+	d = newDurationWindow(0)
+	d.record(time.Second)
+	if stats := d.summary(); stats.Window != DefaultStatsWindow || stats.P50 != time.Second || stats.P99 != time.Second {
+		t.Errorf("Expected 1s at every percentile, got %+v", stats)
+	}
+}
@@ -0,0 +1,168 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"event-service/internal/model"
+	"event-service/internal/store"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// EnrichmentConfig configures the optional enrichment step
+type EnrichmentConfig struct {
+	// URL is the base URL of the lookup service; the key value is appended
+	// as a path segment, e.g. GET {URL}/{user_id}
+	URL string
+	// KeyField is the top-level payload field whose value is looked up
+	KeyField string
+	// TargetField is the payload field the lookup result is stored under
+	TargetField string
+	Timeout     time.Duration
+	CacheTTL    time.Duration
+	// Fatal makes a failed lookup fail processing instead of skipping enrichment
+	Fatal bool
+}
+
+// enricher looks up data for events and merges it into their payload
+type enricher struct {
+	config EnrichmentConfig
+	client *http.Client
+	store  *store.Store
+
+	mu    sync.Mutex
+	cache map[string]cachedLookup
+}
+
+// maxCachedLookups bounds the lookup cache so distinct keys can't grow it forever
+const maxCachedLookups = 10000
+
+type cachedLookup struct {
+	value   json.RawMessage
+	expires time.Time
+}
+
+// EnrichmentMiddleware enriches event payloads via an HTTP lookup before
+// processing, turning the worker into an enrich-then-process pipeline
+func EnrichmentMiddleware(st *store.Store, config EnrichmentConfig) Middleware {
+	e := &enricher{
+		config: config,
+		client: &http.Client{Timeout: config.Timeout},
+		store:  st,
+		cache:  make(map[string]cachedLookup),
+	}
+	return func(next ProcessFunc) ProcessFunc {
+		return func(ctx context.Context, event *model.Event) error {
+			if err := e.enrich(ctx, event); err != nil {
+				if e.config.Fatal {
+					return fmt.Errorf("enrichment failed: %w", err)
+				}
+				log.Printf("Skipping enrichment for event %s: %v", event.EventID, err)
+			}
+			return next(ctx, event)
+		}
+	}
+}
+
+// enrich merges the lookup result into the payload. Payloads that are not
+// objects or don't carry the key field are left untouched.
+func (e *enricher) enrich(ctx context.Context, event *model.Event) error {
+	// Decoding into raw fields keeps every other value byte-for-byte intact
+	var fields map[string]json.RawMessage
+	if err := model.UnmarshalPayload(event.Payload, &fields); err != nil || fields == nil {
+		return nil
+	}
+	rawKey, ok := fields[e.config.KeyField]
+	if !ok {
+		return nil
+	}
+
+	key := lookupKey(rawKey)
+	value, err := e.lookup(ctx, key)
+	if err != nil {
+		return err
+	}
+
+	fields[e.config.TargetField] = value
+	payload, err := json.Marshal(fields)
+	if err != nil {
+		return err
+	}
+	return e.store.SetPayload(event.EventID, payload)
+}
+
+// lookup fetches the enrichment data for a key, using the cache when fresh
+func (e *enricher) lookup(ctx context.Context, key string) (json.RawMessage, error) {
+	e.mu.Lock()
+	cached, ok := e.cache[key]
+	e.mu.Unlock()
+	if ok && time.Now().Before(cached.expires) {
+		return cached.value, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimRight(e.config.URL, "/")+"/"+url.PathEscape(key), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("lookup returned status %d", resp.StatusCode)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if !json.Valid(body) {
+		return nil, fmt.Errorf("lookup returned invalid JSON")
+	}
+
+	if e.config.CacheTTL > 0 {
+		e.mu.Lock()
+		if len(e.cache) >= maxCachedLookups {
+			e.evictExpired()
+		}
+		e.cache[key] = cachedLookup{value: body, expires: time.Now().Add(e.config.CacheTTL)}
+		e.mu.Unlock()
+	}
+	return body, nil
+}
+
+// evictExpired drops expired lookups, or the whole cache if none have
+// expired yet. Caller must hold e.mu.
+func (e *enricher) evictExpired() {
+	now := time.Now()
+	for key, cached := range e.cache {
+		if now.After(cached.expires) {
+			delete(e.cache, key)
+		}
+	}
+	if len(e.cache) >= maxCachedLookups {
+		e.cache = make(map[string]cachedLookup)
+	}
+}
+
+// lookupKey turns the raw key field into a lookup string, unquoting strings
+// and using numbers and other literals exactly as written
+func lookupKey(raw json.RawMessage) string {
+	var value interface{}
+	if err := model.UnmarshalPayload(raw, &value); err == nil {
+		switch v := value.(type) {
+		case string:
+			return v
+		case json.Number:
+			return v.String()
+		}
+	}
+	return string(raw)
+}
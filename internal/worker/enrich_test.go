@@ -0,0 +1,100 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"event-service/internal/model"
+	"event-service/internal/store"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestEnrichmentMergesLookupResult(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		if r.URL.Path != "/user_123" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`{"name": "Ada"}`))
+	}))
+	defer server.Close()
+
+	st := store.New()
+	process := EnrichmentMiddleware(st, EnrichmentConfig{
+		URL:         server.URL,
+		KeyField:    "user_id",
+		TargetField: "user",
+		Timeout:     time.Second,
+		CacheTTL:    time.Minute,
+	})(func(ctx context.Context, event *model.Event) error { return nil })
+
+	for _, id := range []string{"evt_1", "evt_2"} {
+		event := &model.Event{EventID: id, Payload: json.RawMessage(`{"user_id": "user_123", "amount": 12345678901234567890, "rate": 0.1000000000000000055511151231257827}`)}
+		st.Save(event)
+		if err := process(context.Background(), event); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		var payload map[string]json.RawMessage
+		json.Unmarshal(event.Payload, &payload)
+		if string(payload["user"]) != `{"name":"Ada"}` {
+			t.Errorf("Expected enriched user, got %s", payload["user"])
+		}
+		if string(payload["amount"]) != "12345678901234567890" {
+			t.Errorf("Expected amount to survive enrichment, got %s", payload["amount"])
+		}
+		if string(payload["rate"]) != "0.1000000000000000055511151231257827" {
+			t.Errorf("Expected rate to survive enrichment, got %s", payload["rate"])
+		}
+	}
+
+	if calls != 1 {
+		t.Errorf("Expected 1 lookup with caching, got %d", calls)
+	}
+}
+
+func TestEnrichmentFailurePolicy(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	for _, fatal := range []bool{true, false} {
+		st := store.New()
+		event := &model.Event{EventID: "evt_1", Payload: json.RawMessage(`{"user_id": "user_123"}`)}
+		st.Save(event)
+
+		processed := false
+		process := EnrichmentMiddleware(st, EnrichmentConfig{
+			URL:      server.URL,
+			KeyField: "user_id",
+			Timeout:  time.Second,
+			Fatal:    fatal,
+		})(func(ctx context.Context, event *model.Event) error {
+			processed = true
+			return nil
+		})
+
+		err := process(context.Background(), event)
+		if fatal && (err == nil || processed) {
+			t.Errorf("Expected fatal enrichment failure to stop processing, got err=%v processed=%v", err, processed)
+		}
+		if !fatal && (err != nil || !processed) {
+			t.Errorf("Expected skipped enrichment to continue processing, got err=%v processed=%v", err, processed)
+		}
+	}
+}
+
+func TestLookupKeyKeepsLargeIntegers(t *testing.T) {
+	if key := lookupKey(json.RawMessage(`9223372036854775807`)); key != "9223372036854775807" {
+		t.Errorf("Expected integer key to be used as written, got %s", key)
+	}
+	if key := lookupKey(json.RawMessage(`"user_123"`)); key != "user_123" {
+		t.Errorf("Expected string key to be unquoted, got %s", key)
+	}
+}
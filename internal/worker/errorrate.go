@@ -0,0 +1,80 @@
+package worker
+
+import (
+	"sync"
+	"time"
+)
+
+// outcomeBuckets is how many slices the error-rate window is divided into;
+// outcomes leave the window one slice at a time
+const outcomeBuckets = 12
+
+// DefaultErrorRateWindow is used when Config.ErrorRateWindow is not set
+const DefaultErrorRateWindow = time.Minute
+
+// outcomeWindow counts processing attempts and failures over a sliding
+// window. Counts are kept per time slice rather than per attempt, so memory
+// stays constant however many events are processed.
+type outcomeWindow struct {
+	mu      sync.Mutex
+	width   time.Duration // of one slice
+	buckets [outcomeBuckets]outcomeBucket
+}
+
+type outcomeBucket struct {
+	start    time.Time
+	attempts int
+	failed   int
+}
+
+func newOutcomeWindow(window time.Duration) *outcomeWindow {
+	if window <= 0 {
+		window = DefaultErrorRateWindow
+	}
+	width := window / outcomeBuckets
+	if width <= 0 {
+		width = 1
+	}
+	return &outcomeWindow{width: width}
+}
+
+// record counts one attempt at the current time
+func (o *outcomeWindow) record(failed bool) {
+	start := time.Now().Truncate(o.width)
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	b := &o.buckets[(start.UnixNano()/int64(o.width))%outcomeBuckets]
+	if !b.start.Equal(start) {
+		// The slot last held a slice that has left the window
+		*b = outcomeBucket{start: start}
+	}
+	b.attempts++
+	if failed {
+		b.failed++
+	}
+}
+
+// counts returns the attempts and failures within the window
+func (o *outcomeWindow) counts() (attempts, failed int) {
+	oldest := time.Now().Truncate(o.width).Add(-(outcomeBuckets - 1) * o.width)
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	for _, b := range o.buckets {
+		if !b.start.Before(oldest) {
+			attempts += b.attempts
+			failed += b.failed
+		}
+	}
+	return attempts, failed
+}
+
+// RecentErrorRate returns the fraction of processing attempts that failed
+// within the error-rate window, along with the number of attempts it is
+// based on. The rate is 0 when nothing was processed.
+func (w *Worker) RecentErrorRate() (float64, int) {
+	attempts, failed := w.stats.recent.counts()
+	if attempts == 0 {
+		return 0, 0
+	}
+	return float64(failed) / float64(attempts), attempts
+}
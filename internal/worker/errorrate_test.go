@@ -0,0 +1,22 @@
+package worker
+
+import (
+	"testing"
+	"time"
+)
+
+func TestOutcomeWindow(t *testing.T) {
+	o := newOutcomeWindow(120 * time.Millisecond)
+	o.record(true)
+	o.record(true)
+	o.record(false)
+
+	if attempts, failed := o.counts(); attempts != 3 || failed != 2 {
+		t.Errorf("Expected 3 attempts with 2 failed, got %d and %d", attempts, failed)
+	}
+
+	time.Sleep(150 * time.Millisecond)
+	if attempts, failed := o.counts(); attempts != 0 || failed != 0 {
+		t.Errorf("Expected outcomes to leave the window, got %d attempts and %d failed", attempts, failed)
+	}
+}
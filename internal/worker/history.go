@@ -0,0 +1,30 @@
+package worker
+
+import (
+	"context"
+	"event-service/internal/model"
+	"event-service/internal/store"
+	"time"
+)
+
+// HistoryMiddleware records processing start and failures on the event's
+// timeline. The accepted and processed entries are recorded by the store.
+func HistoryMiddleware(st *store.Store) Middleware {
+	return func(next ProcessFunc) ProcessFunc {
+		return func(ctx context.Context, event *model.Event) error {
+			st.RecordHistory(event.EventID, model.HistoryEntry{
+				Type: model.HistoryProcessingStarted,
+				At:   time.Now(),
+			})
+			err := next(ctx, event)
+			if err != nil {
+				st.RecordHistory(event.EventID, model.HistoryEntry{
+					Type:  model.HistoryProcessingFailed,
+					At:    time.Now(),
+					Error: err.Error(),
+				})
+			}
+			return err
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package worker
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// activity tracks when the worker last had something to do
+type activity struct {
+	last   atomic.Int64 // unix nanoseconds of the last enqueue or completed event
+	active atomic.Int32 // events currently being processed
+}
+
+func (a *activity) touch() {
+	a.last.Store(time.Now().UnixNano())
+}
+
+func (a *activity) begin() {
+	a.active.Add(1)
+	a.touch()
+}
+
+func (a *activity) end() {
+	a.touch()
+	a.active.Add(-1)
+}
+
+// IdleFor reports how long the worker has had nothing queued, scheduled or
+// held for a paused type, nothing in progress and nothing enqueued. It
+// returns 0 while there is work.
+func (w *Worker) IdleFor() time.Duration {
+	if w.activity.active.Load() > 0 || w.queue.len() > 0 || w.scheduledCount() > 0 || w.pauser.heldCount() > 0 {
+		return 0
+	}
+	return time.Since(time.Unix(0, w.activity.last.Load()))
+}
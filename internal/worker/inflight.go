@@ -0,0 +1,51 @@
+package worker
+
+import (
+	"event-service/internal/model"
+	"sync"
+)
+
+// inflightBudget caps the total payload bytes of accepted events that have
+// not finished processing. Reservations are keyed by the event itself, so a
+// duplicate submission with the same ID holds its own reservation, and
+// releasing twice or releasing a recovered event that never reserved is
+// harmless.
+type inflightBudget struct {
+	mu       sync.Mutex
+	limit    int64 // 0 = unlimited; usage is still tracked
+	used     int64
+	reserved map[*model.Event]int64
+}
+
+// ReserveBytes claims the event's payload size from the in-flight budget
+// before it is accepted. It returns false if that would exceed the budget.
+func (w *Worker) ReserveBytes(event *model.Event) bool {
+	size := int64(len(event.Payload))
+	b := &w.inflight
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.limit > 0 && b.used+size > b.limit {
+		return false
+	}
+	b.reserved[event] = size
+	b.used += size
+	return true
+}
+
+// ReleaseBytes returns an event's reservation to the budget
+func (w *Worker) ReleaseBytes(event *model.Event) {
+	b := &w.inflight
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if size, ok := b.reserved[event]; ok {
+		b.used -= size
+		delete(b.reserved, event)
+	}
+}
+
+// InflightBytes returns the payload bytes currently reserved
+func (w *Worker) InflightBytes() int64 {
+	w.inflight.mu.Lock()
+	defer w.inflight.mu.Unlock()
+	return w.inflight.used
+}
@@ -0,0 +1,81 @@
+package worker
+
+import (
+	"event-service/internal/model"
+	"sync"
+	"time"
+)
+
+// Bounds of the processing latency samples kept for SLA reporting: samples
+// older than latencyMaxAge are no longer reported, and beyond
+// latencySampleLimit the oldest sample makes room for the newest
+const (
+	latencyMaxAge      = 15 * time.Minute
+	latencySampleLimit = 10000
+)
+
+// latencyWindow keeps the end-to-end latencies of recently processed events
+// in a ring buffer, oldest first
+type latencyWindow struct {
+	mu      sync.Mutex
+	samples []latencySample
+	start   int // index of the oldest sample once the buffer is full
+}
+
+type latencySample struct {
+	at      time.Time
+	latency time.Duration
+}
+
+func newLatencyWindow() *latencyWindow {
+	return &latencyWindow{samples: make([]latencySample, 0, latencySampleLimit)}
+}
+
+// record adds the latency of an event processed now
+func (l *latencyWindow) record(latency time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	sample := latencySample{at: time.Now(), latency: latency}
+	if len(l.samples) < latencySampleLimit {
+		l.samples = append(l.samples, sample)
+		return
+	}
+	l.samples[l.start] = sample
+	l.start = (l.start + 1) % latencySampleLimit
+}
+
+// within counts the samples recorded in the last window, and how many of
+// them took at most threshold
+func (l *latencyWindow) within(window, threshold time.Duration) (processed, met int) {
+	since := time.Now().Add(-min(window, latencyMaxAge))
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for i := range l.samples {
+		sample := l.samples[(l.start+i)%len(l.samples)]
+		if sample.at.Before(since) {
+			continue
+		}
+		processed++
+		if sample.latency <= threshold {
+			met++
+		}
+	}
+	return processed, met
+}
+
+// recordLatency records how long a processed event took from acceptance.
+// Events without an acceptance time (e.g. processed directly in tests) are
+// not counted.
+func (w *Worker) recordLatency(event *model.Event) {
+	if event.CreatedAt.IsZero() {
+		return
+	}
+	w.latencies.record(time.Since(event.CreatedAt))
+}
+
+// SLACompliance returns how many events were processed within the last
+// window (at most 15 minutes, and at most the latest 10000 events), and how
+// many of them within threshold of being accepted
+func (w *Worker) SLACompliance(window, threshold time.Duration) (processed, met int) {
+	return w.latencies.within(window, threshold)
+}
@@ -0,0 +1,40 @@
+package worker
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLatencyWindow(t *testing.T) {
+	l := newLatencyWindow()
+	l.record(100 * time.Millisecond)
+	l.record(2 * time.Second)
+	l.record(500 * time.Millisecond)
+
+	if processed, met := l.within(time.Minute, time.Second); processed != 3 || met != 2 {
+		t.Errorf("Expected 2 of 3 within the threshold, got %d of %d", met, processed)
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	l.record(0)
+	if processed, met := l.within(10*time.Millisecond, time.Second); processed != 1 || met != 1 {
+		t.Errorf("Expected only the latest sample in a short window, got %d of %d", met, processed)
+	}
+}
+
+func TestLatencyWindowIsBounded(t *testing.T) {
+	l := newLatencyWindow()
+	for i := 0; i < latencySampleLimit; i++ {
+		l.record(time.Hour)
+	}
+	for i := 0; i < 10; i++ {
+		l.record(0)
+	}
+
+	if len(l.samples) != latencySampleLimit {
+		t.Fatalf("Expected %d samples kept, got %d", latencySampleLimit, len(l.samples))
+	}
+	if processed, met := l.within(time.Minute, time.Second); processed != latencySampleLimit || met != 10 {
+		t.Errorf("Expected the 10 newest samples to replace the oldest, got %d of %d", met, processed)
+	}
+}
@@ -0,0 +1,46 @@
+package worker
+
+import (
+	"context"
+	"event-service/internal/model"
+	"log"
+)
+
+// ProcessFunc performs the processing work for a single event
+type ProcessFunc func(ctx context.Context, event *model.Event) error
+
+// Middleware wraps a ProcessFunc to run logic before and/or after it,
+// similar to HTTP middleware (logging, metrics, tracing, validation, ...)
+type Middleware func(next ProcessFunc) ProcessFunc
+
+// Chain wraps process with the given middleware. The first middleware is the
+// outermost one, so it runs first before and last after processing.
+func Chain(process ProcessFunc, middleware ...Middleware) ProcessFunc {
+	for i := len(middleware) - 1; i >= 0; i-- {
+		process = middleware[i](process)
+	}
+	return process
+}
+
+// LoggingMiddleware logs the start and outcome of processing
+func LoggingMiddleware(next ProcessFunc) ProcessFunc {
+	return func(ctx context.Context, event *model.Event) error {
+		logEvent(event, "Processing event: %s", event.EventID)
+		if err := next(ctx, event); err != nil {
+			logEvent(event, "Event processing failed: %s: %v", event.EventID, err)
+			return err
+		}
+		logEvent(event, "Event processed: %s", event.EventID)
+		return nil
+	}
+}
+
+// logEvent logs a line about the event, prefixed with the ID of the request
+// that submitted it, if known, like the HTTP handlers' log lines
+func logEvent(event *model.Event, format string, args ...interface{}) {
+	if event.RequestID != "" {
+		args = append([]interface{}{event.RequestID}, args...)
+		format = "[%s] " + format
+	}
+	log.Printf(format, args...)
+}
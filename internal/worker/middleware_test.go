@@ -0,0 +1,64 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"event-service/internal/model"
+	"event-service/internal/store"
+	"reflect"
+	"testing"
+)
+
+func TestChainOrder(t *testing.T) {
+	var calls []string
+	record := func(name string) Middleware {
+		return func(next ProcessFunc) ProcessFunc {
+			return func(ctx context.Context, event *model.Event) error {
+				calls = append(calls, name+":before")
+				err := next(ctx, event)
+				calls = append(calls, name+":after")
+				return err
+			}
+		}
+	}
+
+	process := Chain(func(ctx context.Context, event *model.Event) error {
+		calls = append(calls, "process")
+		return nil
+	}, record("outer"), record("inner"))
+
+	if err := process(context.Background(), &model.Event{EventID: "evt_1"}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	expected := []string{"outer:before", "inner:before", "process", "inner:after", "outer:after"}
+	if !reflect.DeepEqual(calls, expected) {
+		t.Errorf("Expected calls %v, got %v", expected, calls)
+	}
+}
+
+func TestUseMiddlewareCanRejectEvent(t *testing.T) {
+	st := store.New()
+	w := New(st, Config{})
+	w.Use(func(next ProcessFunc) ProcessFunc {
+		return func(ctx context.Context, event *model.Event) error {
+			if event.EventID == "bad" {
+				return errors.New("rejected")
+			}
+			return next(ctx, event)
+		}
+	})
+
+	for _, id := range []string{"good", "bad"} {
+		event := &model.Event{EventID: id, Status: model.StatusAccepted}
+		st.Save(event)
+		w.processEvent(event)
+	}
+
+	if status, _ := st.GetStatus("good"); status != model.StatusProcessed {
+		t.Errorf("Expected good event to be processed, got %s", status)
+	}
+	if status, _ := st.GetStatus("bad"); status != model.StatusFailed {
+		t.Errorf("Expected rejected event to be failed without retries, got %s", status)
+	}
+}
@@ -0,0 +1,83 @@
+package worker
+
+import (
+	"event-service/internal/model"
+	"log"
+	"sync"
+)
+
+// typePauser holds back events of paused types while other types keep
+// processing. Held events are re-enqueued when their type is resumed.
+type typePauser struct {
+	mu     sync.Mutex
+	paused map[string]bool
+	held   map[string][]*model.Event
+}
+
+func newTypePauser() *typePauser {
+	return &typePauser{
+		paused: make(map[string]bool),
+		held:   make(map[string][]*model.Event),
+	}
+}
+
+// hold keeps the event back if its type is paused and reports whether it did
+func (p *typePauser) hold(event *model.Event) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.paused[event.Type] {
+		return false
+	}
+	p.held[event.Type] = append(p.held[event.Type], event)
+	return true
+}
+
+// PauseType stops processing of events with the given type
+func (w *Worker) PauseType(eventType string) {
+	w.pauser.mu.Lock()
+	defer w.pauser.mu.Unlock()
+	w.pauser.paused[eventType] = true
+	log.Printf("Paused processing of event type %q", eventType)
+}
+
+// ResumeType resumes processing of the given type and re-enqueues the
+// events held while it was paused. It returns how many were released.
+func (w *Worker) ResumeType(eventType string) int {
+	w.pauser.mu.Lock()
+	delete(w.pauser.paused, eventType)
+	held := w.pauser.held[eventType]
+	delete(w.pauser.held, eventType)
+	w.pauser.mu.Unlock()
+
+	log.Printf("Resumed processing of event type %q, releasing %d held events", eventType, len(held))
+	// Enqueue in the background so a full queue can't block the caller
+	go func() {
+		for _, event := range held {
+			w.EnqueueWait(event)
+		}
+	}()
+	return len(held)
+}
+
+// PausedTypes returns the currently paused event types with the number of
+// events held for each
+func (w *Worker) PausedTypes() map[string]int {
+	w.pauser.mu.Lock()
+	defer w.pauser.mu.Unlock()
+	paused := make(map[string]int, len(w.pauser.paused))
+	for eventType := range w.pauser.paused {
+		paused[eventType] = len(w.pauser.held[eventType])
+	}
+	return paused
+}
+
+// heldCount returns the total number of held events
+func (p *typePauser) heldCount() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	count := 0
+	for _, events := range p.held {
+		count += len(events)
+	}
+	return count
+}
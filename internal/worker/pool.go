@@ -0,0 +1,82 @@
+package worker
+
+import (
+	"log"
+	"sync"
+)
+
+// pool tracks the processing goroutines of auto mode so their number can
+// change at runtime
+type pool struct {
+	mu          sync.Mutex
+	concurrency int
+	// loops are the running goroutines; nil while the worker is stopped
+	loops []*processingLoop
+}
+
+// processingLoop is one goroutine processing the queue
+type processingLoop struct {
+	stop chan struct{} // closed to retire the loop
+	done chan struct{} // closed once the loop has exited
+}
+
+// retired reports whether the loop was asked to stop
+func (l *processingLoop) retired() bool {
+	select {
+	case <-l.stop:
+		return true
+	default:
+		return false
+	}
+}
+
+// startLoop starts another processing goroutine. Caller must hold w.pool.mu.
+func (w *Worker) startLoop() {
+	l := &processingLoop{stop: make(chan struct{}), done: make(chan struct{})}
+	w.pool.loops = append(w.pool.loops, l)
+	w.loops.Add(1)
+	w.alive.Add(1)
+	go w.loop(l)
+}
+
+// SetConcurrency changes the number of goroutines processing the queue
+// (minimum 1). Retired goroutines finish the event they are processing but
+// take no new ones, and SetConcurrency returns once they have exited, so no
+// event is dropped by scaling down. In manual mode or while the worker is
+// stopped only the concurrency of the next Start changes.
+func (w *Worker) SetConcurrency(n int) {
+	if n < 1 {
+		n = 1
+	}
+	w.pool.mu.Lock()
+	defer w.pool.mu.Unlock()
+	previous := w.pool.concurrency
+	w.pool.concurrency = n
+	if w.pool.loops == nil {
+		return
+	}
+
+	for len(w.pool.loops) < n {
+		w.startLoop()
+	}
+	if len(w.pool.loops) > n {
+		retiring := w.pool.loops[n:]
+		w.pool.loops = w.pool.loops[:n]
+		for _, l := range retiring {
+			close(l.stop)
+		}
+		// Wake retiring goroutines waiting for an event
+		w.queue.wake()
+		for _, l := range retiring {
+			<-l.done
+		}
+	}
+	log.Printf("Worker concurrency changed from %d to %d", previous, n)
+}
+
+// Concurrency returns the number of goroutines processing the queue in auto mode
+func (w *Worker) Concurrency() int {
+	w.pool.mu.Lock()
+	defer w.pool.mu.Unlock()
+	return w.pool.concurrency
+}
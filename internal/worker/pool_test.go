@@ -0,0 +1,58 @@
+package worker
+
+import (
+	"context"
+	"event-service/internal/model"
+	"event-service/internal/store"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestSetConcurrencyScaleDown(t *testing.T) {
+	st := store.New()
+	w := New(st, Config{ProcessingDelayMs: 30, Concurrency: 4})
+	w.Start()
+	defer w.Stop(context.Background())
+
+	const total = 12
+	for i := 0; i < total; i++ {
+		event := &model.Event{EventID: fmt.Sprintf("evt_%d", i), Status: model.StatusAccepted}
+		st.Save(event)
+		w.Enqueue(event)
+	}
+
+	// Scale down while all four goroutines are in the middle of an event
+	time.Sleep(10 * time.Millisecond)
+	w.SetConcurrency(1)
+	if alive := w.alive.Load(); alive != 1 {
+		t.Errorf("Expected 1 processing goroutine after scaling down, got %d", alive)
+	}
+	if stats := w.Stats(); stats.Processed < 4 {
+		t.Errorf("Expected the retired goroutines to finish their events first, got %d processed", stats.Processed)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for w.Stats().Processed < total && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	for i := 0; i < total; i++ {
+		if status, _ := st.GetStatus(fmt.Sprintf("evt_%d", i)); status != model.StatusProcessed {
+			t.Errorf("Expected evt_%d to be processed, got %s", i, status)
+		}
+	}
+}
+
+func TestSetConcurrencyScaleUp(t *testing.T) {
+	w := New(store.New(), Config{Concurrency: 1})
+	w.Start()
+	defer w.Stop(context.Background())
+
+	w.SetConcurrency(3)
+	if alive := w.alive.Load(); alive != 3 {
+		t.Errorf("Expected 3 processing goroutines, got %d", alive)
+	}
+	if concurrency := w.Concurrency(); concurrency != 3 {
+		t.Errorf("Expected concurrency 3, got %d", concurrency)
+	}
+}
@@ -0,0 +1,264 @@
+package worker
+
+import (
+	"errors"
+	"event-service/internal/model"
+	"log"
+	"sort"
+	"sync"
+)
+
+var (
+	// ErrQueueFull is returned when an event finds no place in the queue
+	ErrQueueFull = errors.New("queue is full")
+	// ErrStopped is returned when an event is enqueued once Stop has begun
+	ErrStopped = errors.New("worker is stopped")
+)
+
+// QueueOrder controls which waiting event the worker picks next
+type QueueOrder string
+
+const (
+	// OrderFIFO processes events in the order they were enqueued
+	OrderFIFO QueueOrder = "fifo"
+
+	// OrderLIFO processes the most recently enqueued event first.
+	//
+	// TRADEOFF: during a backlog, LIFO keeps fresh events fast at the expense
+	// of old ones. Under a sustained arrival rate that exceeds processing
+	// capacity, the oldest events can starve indefinitely and are only
+	// processed once the backlog clears (or on shutdown drain).
+	OrderLIFO QueueOrder = "lifo"
+)
+
+// DefaultQueueCapacity is used when Config.QueueCapacity is not set
+const DefaultQueueCapacity = 100
+
+// queue is a bounded, blocking event queue guarded by a condition variable.
+// It replaces a plain buffered channel so that the pick order can be switched
+// between FIFO and LIFO, and so that events of higher priority overtake the
+// ones waiting. The order only applies among events of equal priority.
+//
+// A place can be reserved for an event before it is pushed, so a caller can
+// make sure there is room before committing to the event. Reserved places
+// count as taken.
+type queue struct {
+	mu       sync.Mutex
+	cond     *sync.Cond
+	items    []*model.Event // by descending priority, then enqueue order
+	reserved map[*model.Event]struct{}
+	capacity int
+	order    QueueOrder
+	closed   bool
+	peak     int
+}
+
+// newQueue creates a queue with the given capacity and order.
+// An empty or unknown order falls back to FIFO.
+func newQueue(capacity int, order QueueOrder) *queue {
+	if order == "" {
+		order = OrderFIFO
+	} else if order != OrderFIFO && order != OrderLIFO {
+		log.Printf("Unknown queue order %q, using default: %s", order, OrderFIFO)
+		order = OrderFIFO
+	}
+	if capacity < 1 {
+		capacity = DefaultQueueCapacity
+	}
+	q := &queue{
+		items:    make([]*model.Event, 0, capacity),
+		reserved: make(map[*model.Event]struct{}),
+		capacity: capacity,
+		order:    order,
+	}
+	q.cond = sync.NewCond(&q.mu)
+	return q
+}
+
+// push adds an event, blocking while the queue is full unless the event has
+// a reserved place. Events pushed after close are still accepted so that
+// retries during the drain get processed.
+func (q *queue) push(event *model.Event) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for q.full() && !q.holds(event) && !q.closed {
+		q.cond.Wait()
+	}
+	q.add(event)
+}
+
+// tryPush adds an event like push, but fails with ErrQueueFull instead of
+// blocking when the queue is full, and with ErrStopped once it is closed
+func (q *queue) tryPush(event *model.Event) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if err := q.admit(event); err != nil {
+		return err
+	}
+	q.add(event)
+	return nil
+}
+
+// reserve takes a place in the queue for an event pushed later. It fails
+// like tryPush.
+func (q *queue) reserve(event *model.Event) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if err := q.admit(event); err != nil {
+		return err
+	}
+	q.reserved[event] = struct{}{}
+	return nil
+}
+
+// admit checks that a new event may take a place. Caller must hold q.mu.
+func (q *queue) admit(event *model.Event) error {
+	if q.closed {
+		return ErrStopped
+	}
+	if q.full() && !q.holds(event) {
+		return ErrQueueFull
+	}
+	return nil
+}
+
+// release gives up an event's reserved place, if it has one
+func (q *queue) release(event *model.Event) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.holds(event) {
+		delete(q.reserved, event)
+		q.cond.Broadcast()
+	}
+}
+
+// full reports whether every place is taken. Caller must hold q.mu.
+func (q *queue) full() bool {
+	return len(q.items)+len(q.reserved) >= q.capacity
+}
+
+// holds reports whether the event has a reserved place. Caller must hold q.mu.
+func (q *queue) holds(event *model.Event) bool {
+	_, ok := q.reserved[event]
+	return ok
+}
+
+// add inserts the event behind those of equal or higher priority, using up
+// its reservation. Caller must hold q.mu.
+func (q *queue) add(event *model.Event) {
+	delete(q.reserved, event)
+	i := sort.Search(len(q.items), func(i int) bool { return q.items[i].Priority < event.Priority })
+	q.items = append(q.items, nil)
+	copy(q.items[i+1:], q.items[i:])
+	q.items[i] = event
+	if len(q.items) > q.peak {
+		q.peak = len(q.items)
+	}
+	q.cond.Broadcast()
+}
+
+// pop removes the next event according to the queue order, blocking until
+// one is available. It returns false once the queue has been closed or stop
+// is closed; call wake after closing stop so a waiting pop notices.
+func (q *queue) pop(stop <-chan struct{}) (*model.Event, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for {
+		select {
+		case <-stop:
+			return nil, false
+		default:
+		}
+		if q.closed {
+			return nil, false
+		}
+		if len(q.items) > 0 {
+			return q.take(), true
+		}
+		q.cond.Wait()
+	}
+}
+
+// wake wakes up all waiters so they re-check their stop channels
+func (q *queue) wake() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.cond.Broadcast()
+}
+
+// tryPop removes the next event without blocking, even after close.
+// It is used to drain remaining events on shutdown.
+func (q *queue) tryPop() (*model.Event, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.items) == 0 {
+		return nil, false
+	}
+	return q.take(), true
+}
+
+// take removes and returns the next event: the first or, with LIFO, the
+// last enqueued one of the highest priority. Caller must hold q.mu and
+// ensure the queue is not empty.
+func (q *queue) take() *model.Event {
+	var event *model.Event
+	if q.order == OrderLIFO {
+		top := q.items[0].Priority
+		i := sort.Search(len(q.items), func(i int) bool { return q.items[i].Priority < top }) - 1
+		event = q.items[i]
+		last := len(q.items) - 1
+		copy(q.items[i:], q.items[i+1:])
+		q.items[last] = nil
+		q.items = q.items[:last]
+	} else {
+		event = q.items[0]
+		q.items[0] = nil
+		q.items = q.items[1:]
+	}
+	q.cond.Broadcast()
+	return event
+}
+
+// close wakes up all waiters; pop returns false from now on
+func (q *queue) close() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.closed = true
+	q.cond.Broadcast()
+}
+
+// reopen makes a closed queue usable again, keeping any waiting events
+func (q *queue) reopen() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.closed = false
+}
+
+// isClosed reports whether the queue has been closed
+func (q *queue) isClosed() bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.closed
+}
+
+// isFull reports whether every place is taken, by a waiting event or a
+// reservation
+func (q *queue) isFull() bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.full()
+}
+
+// len returns the number of waiting events
+func (q *queue) len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.items)
+}
+
+// peakDepth returns the highest number of events that were waiting at once
+func (q *queue) peakDepth() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.peak
+}
@@ -0,0 +1,116 @@
+package worker
+
+import (
+	"event-service/internal/model"
+	"testing"
+)
+
+func TestQueueOrder(t *testing.T) {
+	tests := []struct {
+		order    QueueOrder
+		expected []string
+	}{
+		{OrderFIFO, []string{"a", "b", "c"}},
+		{OrderLIFO, []string{"c", "b", "a"}},
+	}
+
+	for _, tt := range tests {
+		q := newQueue(10, tt.order)
+		for _, id := range []string{"a", "b", "c"} {
+			q.push(&model.Event{EventID: id})
+		}
+
+		for _, want := range tt.expected {
+			event, ok := q.pop(nil)
+			if !ok {
+				t.Fatalf("%s: expected event %s, queue was closed", tt.order, want)
+			}
+			if event.EventID != want {
+				t.Errorf("%s: expected event %s, got %s", tt.order, want, event.EventID)
+			}
+		}
+	}
+}
+
+func TestQueuePriority(t *testing.T) {
+	tests := []struct {
+		order    QueueOrder
+		expected []string
+	}{
+		{OrderFIFO, []string{"high_1", "high_2", "mid", "low_1", "low_2"}},
+		{OrderLIFO, []string{"high_2", "high_1", "mid", "low_2", "low_1"}},
+	}
+
+	for _, tt := range tests {
+		q := newQueue(10, tt.order)
+		for _, event := range []*model.Event{
+			{EventID: "low_1"},
+			{EventID: "high_1", Priority: 5},
+			{EventID: "mid", Priority: 1},
+			{EventID: "low_2"},
+			{EventID: "high_2", Priority: 5},
+		} {
+			q.push(event)
+		}
+
+		for _, want := range tt.expected {
+			if event, _ := q.tryPop(); event.EventID != want {
+				t.Errorf("%s: expected event %s, got %s", tt.order, want, event.EventID)
+			}
+		}
+	}
+}
+
+func TestQueueClose(t *testing.T) {
+	q := newQueue(10, OrderFIFO)
+	q.push(&model.Event{EventID: "a"})
+	q.close()
+
+	if _, ok := q.pop(nil); ok {
+		t.Error("Expected pop to return false after close")
+	}
+	if event, ok := q.tryPop(); !ok || event.EventID != "a" {
+		t.Error("Expected tryPop to drain remaining event after close")
+	}
+}
+
+func TestQueueReservations(t *testing.T) {
+	q := newQueue(2, OrderFIFO)
+	a, b, c := &model.Event{EventID: "a"}, &model.Event{EventID: "b"}, &model.Event{EventID: "c"}
+
+	if q.tryPush(a) != nil || q.reserve(b) != nil {
+		t.Fatal("Expected room for a and a reservation for b")
+	}
+	// The reservation takes the last place
+	if q.reserve(c) != ErrQueueFull || q.tryPush(c) != ErrQueueFull {
+		t.Error("Expected the queue to be full")
+	}
+	if q.tryPush(b) != nil {
+		t.Error("Expected b to use its reservation")
+	}
+
+	q.pop(nil)
+	if q.reserve(c) != nil {
+		t.Fatal("Expected room for c after a pop")
+	}
+	q.release(c)
+	if q.tryPush(&model.Event{EventID: "d"}) != nil {
+		t.Error("Expected a released reservation to free its place")
+	}
+}
+
+func TestQueueRejectsAfterClose(t *testing.T) {
+	q := newQueue(10, OrderFIFO)
+	q.close()
+
+	if err := q.tryPush(&model.Event{EventID: "a"}); err != ErrStopped {
+		t.Errorf("Expected ErrStopped, got %v", err)
+	}
+	if err := q.reserve(&model.Event{EventID: "a"}); err != ErrStopped {
+		t.Errorf("Expected ErrStopped, got %v", err)
+	}
+	q.reopen()
+	if err := q.tryPush(&model.Event{EventID: "a"}); err != nil {
+		t.Errorf("Expected a reopened queue to take events, got %v", err)
+	}
+}
@@ -0,0 +1,39 @@
+package worker
+
+import (
+	"errors"
+	"event-service/internal/store"
+	"time"
+)
+
+// retryStore runs a store mutation up to attempts times, doubling the backoff
+// between tries. ErrNotFound is permanent and is returned without retrying.
+func retryStore(attempts int, backoff time.Duration, op func() error) error {
+	var err error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		if err = op(); err == nil || errors.Is(err, store.ErrNotFound) {
+			return err
+		}
+		if attempt < attempts {
+			time.Sleep(backoff)
+			backoff *= 2
+		}
+	}
+	return err
+}
+
+// retryDelay returns the backoff before the retry that follows the given
+// attempt: base for the first, doubling per attempt, capped at max when set
+func retryDelay(attempt int, base, max time.Duration) time.Duration {
+	delay := base
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if max > 0 && delay >= max {
+			return max
+		}
+	}
+	if max > 0 && delay > max {
+		return max
+	}
+	return delay
+}
@@ -0,0 +1,112 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"event-service/internal/model"
+	"event-service/internal/store"
+	"testing"
+	"time"
+)
+
+func TestRetryStoreRecoversFromTransientFailure(t *testing.T) {
+	calls := 0
+	err := retryStore(3, time.Millisecond, func() error {
+		calls++
+		if calls < 3 {
+			return errors.New("connection reset")
+		}
+		return nil
+	})
+
+	if err != nil {
+		t.Errorf("Expected success on third attempt, got %v", err)
+	}
+	if calls != 3 {
+		t.Errorf("Expected 3 calls, got %d", calls)
+	}
+}
+
+func TestRetryStoreGivesUp(t *testing.T) {
+	calls := 0
+	err := retryStore(3, time.Millisecond, func() error {
+		calls++
+		return errors.New("connection reset")
+	})
+
+	if err == nil {
+		t.Error("Expected error after exhausting retries")
+	}
+	if calls != 3 {
+		t.Errorf("Expected 3 calls, got %d", calls)
+	}
+}
+
+func TestRetryStoreDoesNotRetryNotFound(t *testing.T) {
+	calls := 0
+	err := retryStore(3, time.Millisecond, func() error {
+		calls++
+		return store.ErrNotFound
+	})
+
+	if !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("Expected 1 call, got %d", calls)
+	}
+}
+
+func TestFailedProcessingIsRetriedThenFailed(t *testing.T) {
+	st := store.New()
+	w := New(st, Config{Mode: ModeManual, MaxRetries: 2, RetryBackoffMs: 1})
+	calls := 0
+	w.Use(func(_ ProcessFunc) ProcessFunc {
+		return func(ctx context.Context, event *model.Event) error {
+			calls++
+			return errors.New("downstream unavailable")
+		}
+	})
+	w.Start()
+	defer w.Stop(context.Background())
+
+	event := &model.Event{EventID: "evt_1", Status: model.StatusAccepted}
+	st.Save(event)
+	w.Enqueue(event)
+
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		if status, _ := st.GetStatus("evt_1"); status == model.StatusFailed {
+			break
+		}
+		w.Tick(1)
+		time.Sleep(time.Millisecond)
+	}
+
+	stored, _ := st.Get("evt_1")
+	if stored.Status != model.StatusFailed {
+		t.Fatalf("Expected event to be failed after retries, got %s", stored.Status)
+	}
+	if calls != 3 || stored.Attempts != 3 {
+		t.Errorf("Expected 3 attempts (1 + 2 retries), got %d calls and %d recorded", calls, stored.Attempts)
+	}
+	if stats := w.Stats(); stats.Failed != 1 || stats.FailedAttempts != 3 {
+		t.Errorf("Expected 1 failed event after 3 failed attempts, got %d and %d", stats.Failed, stats.FailedAttempts)
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	for _, tc := range []struct {
+		attempt  int
+		expected time.Duration
+	}{
+		{1, time.Second},
+		{2, 2 * time.Second},
+		{3, 4 * time.Second},
+		{10, 5 * time.Second},
+	} {
+		if delay := retryDelay(tc.attempt, time.Second, 5*time.Second); delay != tc.expected {
+			t.Errorf("Attempt %d: expected %v, got %v", tc.attempt, tc.expected, delay)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package worker
+
+import (
+	"container/heap"
+	"event-service/internal/model"
+	"log"
+	"sync"
+	"time"
+)
+
+// delayHeap orders scheduled events by their due time, earliest first
+type delayHeap []*model.Event
+
+func (h delayHeap) Len() int            { return len(h) }
+func (h delayHeap) Less(i, j int) bool  { return h[i].ProcessAt.Before(h[j].ProcessAt) }
+func (h delayHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *delayHeap) Push(x interface{}) { *h = append(*h, x.(*model.Event)) }
+func (h *delayHeap) Pop() interface{} {
+	old := *h
+	event := old[len(old)-1]
+	old[len(old)-1] = nil
+	*h = old[:len(old)-1]
+	return event
+}
+
+// scheduler holds events until their process_at time and then moves them
+// to the processing queue
+type scheduler struct {
+	mu      sync.Mutex
+	pending delayHeap
+	wake    chan struct{}
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+func newScheduler() *scheduler {
+	return &scheduler{wake: make(chan struct{}, 1)}
+}
+
+// schedule adds an event that is not due yet
+func (w *Worker) schedule(event *model.Event) {
+	s := w.scheduler
+	s.mu.Lock()
+	heap.Push(&s.pending, event)
+	s.mu.Unlock()
+
+	// Wake the scheduler in case this event is due before the one it waits for
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// scheduledCount returns the number of events waiting for their time
+func (w *Worker) scheduledCount() int {
+	w.scheduler.mu.Lock()
+	defer w.scheduler.mu.Unlock()
+	return len(w.scheduler.pending)
+}
+
+// startScheduler runs the scheduler until stopScheduler is called
+func (w *Worker) startScheduler() {
+	s := w.scheduler
+	s.stop = make(chan struct{})
+	s.done = make(chan struct{})
+	go w.runScheduler(s.stop, s.done)
+}
+
+// stopScheduler stops moving due events; events not yet due stay scheduled
+// in the store for the next Recover
+func (w *Worker) stopScheduler() {
+	s := w.scheduler
+	if s.stop == nil {
+		return
+	}
+	close(s.stop)
+	<-s.done
+	s.stop = nil
+	if pending := w.scheduledCount(); pending > 0 {
+		log.Printf("%d scheduled events are not due yet and were left in the store", pending)
+	}
+}
+
+func (w *Worker) runScheduler(stop, done chan struct{}) {
+	defer close(done)
+	s := w.scheduler
+	timer := time.NewTimer(time.Hour)
+	defer timer.Stop()
+
+	for {
+		s.mu.Lock()
+		var due *model.Event
+		wait := time.Hour
+		if len(s.pending) > 0 {
+			if wait = time.Until(s.pending[0].ProcessAt); wait <= 0 {
+				due = heap.Pop(&s.pending).(*model.Event)
+			}
+		}
+		s.mu.Unlock()
+
+		if due != nil {
+			w.release(due)
+			continue
+		}
+
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+		timer.Reset(wait)
+		select {
+		case <-timer.C:
+		case <-s.wake:
+		case <-stop:
+			return
+		}
+	}
+}
+
+// release marks a due event accepted again and hands it to the queue
+func (w *Worker) release(event *model.Event) {
+	if err := w.store.MarkDue(event.EventID); err != nil {
+		log.Printf("Dropping scheduled event %s: %v", event.EventID, err)
+		w.commits.skip(event)
+		return
+	}
+	log.Printf("Scheduled event %s is due", event.EventID)
+	w.push(event)
+}
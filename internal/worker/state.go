@@ -0,0 +1,69 @@
+package worker
+
+import (
+	"log"
+	"sync"
+)
+
+// State is a step in the worker lifecycle
+type State string
+
+const (
+	StateStarting State = "starting"
+	StateRunning  State = "running"
+	// StatePaused means the worker started in manual mode and only
+	// advances when Tick is called
+	StatePaused   State = "paused"
+	StateDraining State = "draining"
+	StateStopped  State = "stopped"
+)
+
+// stateObserverBuffer is how many transitions a slow callback may fall
+// behind before further ones are dropped for it
+const stateObserverBuffer = 16
+
+// stateNotifier tracks the current state and fans transitions out to
+// registered callbacks
+type stateNotifier struct {
+	mu        sync.Mutex
+	state     State
+	observers []chan State
+}
+
+// OnStateChange registers fn to be called with every subsequent state
+// transition. Each callback runs on its own goroutine and sees transitions
+// in order, so a slow callback never stalls the worker; if it falls too far
+// behind, transitions are dropped for that callback only.
+func (w *Worker) OnStateChange(fn func(State)) {
+	ch := make(chan State, stateObserverBuffer)
+	go func() {
+		for state := range ch {
+			fn(state)
+		}
+	}()
+
+	w.states.mu.Lock()
+	defer w.states.mu.Unlock()
+	w.states.observers = append(w.states.observers, ch)
+}
+
+// State returns the current lifecycle state; it is empty before Start
+func (w *Worker) State() State {
+	w.states.mu.Lock()
+	defer w.states.mu.Unlock()
+	return w.states.state
+}
+
+// setState records a transition and notifies observers without blocking
+func (w *Worker) setState(state State) {
+	w.states.mu.Lock()
+	defer w.states.mu.Unlock()
+	w.states.state = state
+	for _, ch := range w.states.observers {
+		select {
+		case ch <- state:
+		default:
+			log.Printf("State observer is falling behind, dropping transition to %s", state)
+		}
+	}
+}
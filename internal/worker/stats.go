@@ -0,0 +1,71 @@
+package worker
+
+import (
+	"context"
+	"event-service/internal/model"
+	"sync/atomic"
+	"time"
+)
+
+// Stats holds lifetime processing counters for the worker. Started counts
+// processing attempts once any PROCESS_RATE_PER_SEC throttle let them
+// through. Failed counts events marked failed once their retries ran out;
+// FailedAttempts counts every failed attempt, including those that were
+// retried.
+type Stats struct {
+	Started           int64
+	Processed         int64
+	Failed            int64
+	FailedAttempts    int64
+	AvgProcessingTime time.Duration
+	PeakQueueDepth    int
+}
+
+// workerStats accumulates lifetime counters, safe for concurrent use
+type workerStats struct {
+	started         atomic.Int64
+	processed       atomic.Int64
+	failed          atomic.Int64
+	failedAttempts  atomic.Int64
+	processingNanos atomic.Int64
+	// recent counts outcomes within the error-rate window
+	recent *outcomeWindow
+	// durations keeps the processing times of the latest attempts
+	durations *durationWindow
+}
+
+// middleware counts outcomes and measures processing time of the rest of the chain
+func (s *workerStats) middleware(next ProcessFunc) ProcessFunc {
+	return func(ctx context.Context, event *model.Event) error {
+		s.started.Add(1)
+		start := time.Now()
+		err := next(ctx, event)
+		elapsed := time.Since(start)
+		s.processingNanos.Add(int64(elapsed))
+		s.durations.record(elapsed)
+		if err != nil {
+			s.failedAttempts.Add(1)
+		} else {
+			s.processed.Add(1)
+		}
+		s.recent.record(err != nil)
+		return err
+	}
+}
+
+// Stats returns the worker's lifetime counters
+func (w *Worker) Stats() Stats {
+	processed := w.stats.processed.Load()
+	failedAttempts := w.stats.failedAttempts.Load()
+	stats := Stats{
+		Started:        w.stats.started.Load(),
+		Processed:      processed,
+		Failed:         w.stats.failed.Load(),
+		FailedAttempts: failedAttempts,
+		PeakQueueDepth: w.queue.peakDepth(),
+	}
+	if total := processed + failedAttempts; total > 0 {
+		stats.AvgProcessingTime = time.Duration(w.stats.processingNanos.Load() / total)
+	}
+	return stats
+}
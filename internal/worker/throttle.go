@@ -0,0 +1,45 @@
+package worker
+
+import (
+	"context"
+	"event-service/internal/model"
+	"sync"
+	"time"
+)
+
+// throttle spaces out calls so they happen at most ratePerSec times per second
+type throttle struct {
+	mu       sync.Mutex
+	interval time.Duration
+	next     time.Time
+}
+
+func newThrottle(ratePerSec int) *throttle {
+	return &throttle{interval: time.Second / time.Duration(ratePerSec)}
+}
+
+// wait blocks until the caller's processing slot arrives
+func (t *throttle) wait() {
+	t.mu.Lock()
+	now := time.Now()
+	if t.next.Before(now) {
+		t.next = now
+	}
+	delay := t.next.Sub(now)
+	t.next = t.next.Add(t.interval)
+	t.mu.Unlock()
+
+	time.Sleep(delay)
+}
+
+// ThrottleMiddleware limits processing to at most ratePerSec events per
+// second, shared across everything that uses the returned middleware
+func ThrottleMiddleware(ratePerSec int) Middleware {
+	t := newThrottle(ratePerSec)
+	return func(next ProcessFunc) ProcessFunc {
+		return func(ctx context.Context, event *model.Event) error {
+			t.wait()
+			return next(ctx, event)
+		}
+	}
+}
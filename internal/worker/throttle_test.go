@@ -0,0 +1,58 @@
+package worker
+
+import (
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestThrottleSpacesCalls(t *testing.T) {
+	th := newThrottle(50) // one slot every 20ms
+
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		th.wait()
+		// A burst followed by a long wait would still take long enough in
+		// total, so check that no call gets its slot early
+		if elapsed := time.Since(start); elapsed < time.Duration(i)*20*time.Millisecond {
+			t.Fatalf("Expected call %d no earlier than %v, got it after %v", i+1, time.Duration(i)*20*time.Millisecond, elapsed)
+		}
+	}
+	elapsed := time.Since(start)
+
+	// The first call is immediate, the remaining four wait 20ms each
+	if elapsed < 80*time.Millisecond || elapsed > 200*time.Millisecond {
+		t.Errorf("Expected about 80ms for 5 calls at 50/s, took %v", elapsed)
+	}
+}
+
+func TestThrottleIsSharedAcrossGoroutines(t *testing.T) {
+	th := newThrottle(100) // one slot every 10ms
+
+	start := time.Now()
+	var mu sync.Mutex
+	var slots []time.Duration
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			th.wait()
+			mu.Lock()
+			slots = append(slots, time.Since(start))
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	sort.Slice(slots, func(i, j int) bool { return slots[i] < slots[j] })
+	for i, slot := range slots {
+		if slot < time.Duration(i)*10*time.Millisecond {
+			t.Fatalf("Expected slot %d no earlier than %v, got %v", i+1, time.Duration(i)*10*time.Millisecond, slot)
+		}
+	}
+	if last := slots[len(slots)-1]; last > 200*time.Millisecond {
+		t.Errorf("Expected 10 calls at 100/s to take about 90ms, took %v", last)
+	}
+}
@@ -0,0 +1,37 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"event-service/internal/model"
+	"fmt"
+	"time"
+)
+
+// TimeoutMiddleware cancels processing that runs longer than the timeout for
+// the event's type, falling back to defaultTimeout. A zero timeout disables
+// the limit. The timeout is surfaced in the returned error.
+func TimeoutMiddleware(defaultTimeout time.Duration, byType map[string]time.Duration) Middleware {
+	return func(next ProcessFunc) ProcessFunc {
+		return func(ctx context.Context, event *model.Event) error {
+			timeout, ok := byType[event.Type]
+			if !ok {
+				timeout = defaultTimeout
+			}
+			if timeout <= 0 {
+				return next(ctx, event)
+			}
+
+			ctx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+			err := next(ctx, event)
+			if errors.Is(err, context.DeadlineExceeded) {
+				if event.Type != "" {
+					return fmt.Errorf("processing timed out after %v (type %q): %w", timeout, event.Type, err)
+				}
+				return fmt.Errorf("processing timed out after %v: %w", timeout, err)
+			}
+			return err
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"event-service/internal/model"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTimeoutMiddlewarePerType(t *testing.T) {
+	slow := func(ctx context.Context, event *model.Event) error {
+		select {
+		case <-time.After(50 * time.Millisecond):
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	process := TimeoutMiddleware(10*time.Millisecond, map[string]time.Duration{
+		"report": time.Second,
+	})(slow)
+
+	err := process(context.Background(), &model.Event{EventID: "evt_1", Type: "order"})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected default timeout to apply to order, got %v", err)
+	}
+	if !strings.Contains(err.Error(), `type "order"`) {
+		t.Errorf("Expected the type in the failure reason, got %v", err)
+	}
+
+	if err := process(context.Background(), &model.Event{EventID: "evt_2", Type: "report"}); err != nil {
+		t.Errorf("Expected report override to allow slow processing, got %v", err)
+	}
+}
@@ -0,0 +1,45 @@
+package worker
+
+import (
+	"context"
+	"event-service/internal/model"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracerName identifies the spans the worker starts
+const tracerName = "event-service/internal/worker"
+
+// startProcessingSpan starts the span of one processing attempt. It is a
+// child of the span of the request that submitted the event, when the event
+// carries its trace context, so the asynchronous leg shows up in the same
+// trace; the link marks it as following from that request rather than
+// running inside it.
+func startProcessingSpan(event *model.Event, attempt int) (context.Context, trace.Span) {
+	parent := otel.GetTextMapPropagator().Extract(context.Background(), propagation.MapCarrier(event.TraceContext))
+	opts := []trace.SpanStartOption{
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			attribute.String("event.id", event.EventID),
+			attribute.String("event.type", event.Type),
+			attribute.Int("event.attempt", attempt),
+		),
+	}
+	if submitted := trace.SpanContextFromContext(parent); submitted.IsValid() {
+		opts = append(opts, trace.WithLinks(trace.Link{SpanContext: submitted}))
+	}
+	return otel.Tracer(tracerName).Start(parent, "process event", opts...)
+}
+
+// endProcessingSpan records the outcome of the attempt and ends its span
+func endProcessingSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -1,79 +1,587 @@
 package worker
 
 import (
-	"log"
+	"context"
+	"errors"
 	"event-service/internal/model"
 	"event-service/internal/store"
+	"log"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
+// Mode controls how the worker advances through the queue
+type Mode string
+
+const (
+	// ModeAuto processes events continuously in the background
+	ModeAuto Mode = "auto"
+	// ModeManual only processes events when Tick is called, which makes
+	// tests and demos deterministic
+	ModeManual Mode = "manual"
+)
+
+// Config holds the worker configuration
+type Config struct {
+	ProcessingDelayMs int
+	QueueOrder        QueueOrder
+	Mode              Mode
+
+	// QueueCapacity is how many due events may wait in the queue
+	// (0 = DefaultQueueCapacity)
+	QueueCapacity int
+
+	// Concurrency is the number of goroutines processing the queue in auto
+	// mode (minimum 1); SetConcurrency changes it at runtime
+	Concurrency int
+
+	// ShutdownHandoff leaves queued events in the store as accepted on Stop
+	// instead of draining them, so the next instance recovers them. It
+	// needs a persistent store and is ignored without one, as the events
+	// would be lost.
+	ShutdownHandoff bool
+
+	// StoreRetryAttempts and StoreRetryBackoffMs control retries of failed
+	// store updates; StoreRetryRequeue re-enqueues the event once they run out.
+	StoreRetryAttempts  int
+	StoreRetryBackoffMs int
+	StoreRetryRequeue   bool
+
+	// MaxRetries is how often failed processing is retried before the event
+	// is marked failed. Retries back off exponentially from RetryBackoffMs,
+	// capped at RetryBackoffMaxMs (0 = no cap).
+	MaxRetries        int
+	RetryBackoffMs    int
+	RetryBackoffMaxMs int
+
+	// ProcessRatePerSec caps how many events are processed per second (0 = unlimited)
+	ProcessRatePerSec int
+
+	// Enrichment enables the enrichment step before processing when set
+	Enrichment *EnrichmentConfig
+
+	// MaxInflightBytes caps the payload bytes reserved by events that are
+	// accepted but not yet done (0 = unlimited)
+	MaxInflightBytes int64
+
+	// MaxInflight caps how many events are processed at once, independently
+	// of Concurrency, e.g. to protect a fragile downstream (0 = no cap)
+	MaxInflight int
+
+	// ProcessingTimeout cancels processing that takes longer (0 = no limit);
+	// ProcessingTimeoutByType overrides it for specific event types
+	ProcessingTimeout       time.Duration
+	ProcessingTimeoutByType map[string]time.Duration
+
+	// Checkpoints lets processors persist their progress with SaveCheckpoint
+	Checkpoints bool
+
+	// ErrorRateWindow is the sliding window of RecentErrorRate
+	// (0 = DefaultErrorRateWindow)
+	ErrorRateWindow time.Duration
+
+	// OrderedCommit marks events processed in the order they were queued,
+	// even though Concurrency processes them in parallel
+	OrderedCommit bool
+
+	// StatsWindow is how many recent processing durations DurationStats
+	// covers (0 = DefaultStatsWindow)
+	StatsWindow int
+
+	// Callbacks configures delivery to events' callback URLs
+	Callbacks CallbackConfig
+}
+
 // Worker processes events asynchronously in the background
 type Worker struct {
-	queue          chan *model.Event
-	store          *store.Store
+	queue           *queue
+	store           *store.Store
 	processingDelay time.Duration
-	running        bool
-	done           chan struct{}
+	shutdownHandoff bool
+	mode            Mode
+	// running is only used in manual mode; in auto mode the worker runs while
+	// any of its processing goroutines is alive
+	running atomic.Bool
+	alive   atomic.Int32
+	loops   sync.WaitGroup
+	pool    pool
+	// slots is a semaphore of MaxInflight processing slots, nil without a cap
+	slots chan struct{}
+	// abandon tells draining goroutines to stop once the Stop deadline passed
+	abandon atomic.Bool
+
+	storeRetryAttempts int
+	storeRetryBackoff  time.Duration
+	storeRetryRequeue  bool
+
+	maxRetries      int
+	retryBackoff    time.Duration
+	retryBackoffMax time.Duration
+
+	pauser   *typePauser
+	stats    workerStats
+	activity activity
+	states   stateNotifier
+	inflight inflightBudget
+	// latencies of recently processed events, for SLA reporting
+	latencies *latencyWindow
+
+	scheduler *scheduler
+	commits   *orderedCommits
+	callbacks *notifier
+
+	middleware []Middleware
+	process    ProcessFunc
 }
 
 // New creates a new background worker
-func New(store *store.Store, processingDelayMs int) *Worker {
-	return &Worker{
-		queue:           make(chan *model.Event, 100), // buffered channel
+func New(store *store.Store, config Config) *Worker {
+	w := &Worker{
+		queue:           newQueue(config.QueueCapacity, config.QueueOrder),
 		store:           store,
-		processingDelay: time.Duration(processingDelayMs) * time.Millisecond,
-		done:            make(chan struct{}),
+		processingDelay: time.Duration(config.ProcessingDelayMs) * time.Millisecond,
+		shutdownHandoff: config.ShutdownHandoff,
+		mode:            config.Mode,
+		pool:            pool{concurrency: config.Concurrency},
+		pauser:          newTypePauser(),
+		scheduler:       newScheduler(),
+		inflight:        inflightBudget{limit: config.MaxInflightBytes, reserved: make(map[*model.Event]int64)},
+
+		storeRetryAttempts: config.StoreRetryAttempts,
+		storeRetryBackoff:  time.Duration(config.StoreRetryBackoffMs) * time.Millisecond,
+		storeRetryRequeue:  config.StoreRetryRequeue,
+
+		maxRetries:      config.MaxRetries,
+		retryBackoff:    time.Duration(config.RetryBackoffMs) * time.Millisecond,
+		retryBackoffMax: time.Duration(config.RetryBackoffMaxMs) * time.Millisecond,
+	}
+	if w.mode == "" {
+		w.mode = ModeAuto
+	} else if w.mode != ModeAuto && w.mode != ModeManual {
+		log.Printf("Unknown worker mode %q, using default: %s", w.mode, ModeAuto)
+		w.mode = ModeAuto
+	}
+	if w.pool.concurrency < 1 {
+		w.pool.concurrency = 1
 	}
+	if config.MaxInflight > 0 {
+		w.slots = make(chan struct{}, config.MaxInflight)
+	}
+	if w.shutdownHandoff && !store.Persistent() {
+		log.Println("ERROR: shutdown handoff needs a persistent store backend, draining the queue on shutdown instead")
+		w.shutdownHandoff = false
+	}
+	if w.storeRetryAttempts < 1 {
+		w.storeRetryAttempts = 1
+	}
+	if w.maxRetries < 0 {
+		w.maxRetries = 0
+	}
+	w.stats.recent = newOutcomeWindow(config.ErrorRateWindow)
+	w.stats.durations = newDurationWindow(config.StatsWindow)
+	w.latencies = newLatencyWindow()
+	w.callbacks = newNotifier(store, config.Callbacks)
+	if config.OrderedCommit {
+		w.commits = newOrderedCommits()
+	}
+	if config.ProcessRatePerSec > 0 {
+		// Throttle first so waiting for a slot isn't logged or timed as processing
+		w.middleware = append(w.middleware, ThrottleMiddleware(config.ProcessRatePerSec))
+	}
+	w.middleware = append(w.middleware, w.stats.middleware, LoggingMiddleware, HistoryMiddleware(store))
+	if config.ProcessingTimeout > 0 || len(config.ProcessingTimeoutByType) > 0 {
+		w.middleware = append(w.middleware, TimeoutMiddleware(config.ProcessingTimeout, config.ProcessingTimeoutByType))
+	}
+	if config.Enrichment != nil {
+		w.middleware = append(w.middleware, EnrichmentMiddleware(store, *config.Enrichment))
+	}
+	if config.Checkpoints {
+		w.middleware = append(w.middleware, CheckpointMiddleware(store))
+	}
+	w.process = Chain(w.simulate, w.middleware...)
+	return w
+}
+
+// Use appends middleware to the processing chain. Middleware added later
+// runs closer to the actual processing. Use must be called before Start.
+func (w *Worker) Use(middleware ...Middleware) {
+	w.middleware = append(w.middleware, middleware...)
+	w.process = Chain(w.simulate, w.middleware...)
 }
 
-// Start begins processing events from the queue
+// Start begins processing events from the queue.
+// In manual mode events are only processed by Tick. A stopped worker can be
+// started again; events enqueued with EnqueueWait while it was stopped are
+// kept.
 func (w *Worker) Start() {
-	w.running = true
-	log.Printf("Worker started with processing delay: %v", w.processingDelay)
+	w.setState(StateStarting)
+	w.abandon.Store(false)
+	w.queue.reopen()
+	w.activity.touch()
+	w.startScheduler()
+	w.pool.mu.Lock()
+	defer w.pool.mu.Unlock()
+	log.Printf("Worker started with processing delay: %v, queue order: %s, mode: %s, concurrency: %d, ordered commit: %t", w.processingDelay, w.queue.order, w.mode, w.pool.concurrency, w.commits != nil)
+	if w.mode == ModeManual {
+		w.running.Store(true)
+		w.setState(StatePaused)
+		return
+	}
+	w.setState(StateRunning)
+
+	w.pool.loops = nil
+	for i := 0; i < w.pool.concurrency; i++ {
+		w.startLoop()
+	}
+}
+
+// loop processes events until the queue is closed, then drains what is left
+// unless queued events are handed off. A retired loop exits right away.
+func (w *Worker) loop(l *processingLoop) {
+	defer close(l.done)
+	defer w.loops.Done()
+	defer w.alive.Add(-1)
+	for {
+		event, ok := w.queue.pop(l.stop)
+		if !ok {
+			break
+		}
+		w.processEvent(event)
+	}
+	if w.shutdownHandoff || l.retired() {
+		return
+	}
+	for !w.abandon.Load() {
+		event, ok := w.queue.tryPop()
+		if !ok {
+			return
+		}
+		w.processEvent(event)
+	}
+}
+
+// Recover re-enqueues events left unprocessed by a previous instance and
+// returns how many were recovered
+func (w *Worker) Recover() int {
+	events := w.store.ListUnprocessed()
+	resumable := 0
+	for _, event := range events {
+		// The event carries its last checkpoint, so processing can resume
+		if len(event.Checkpoint) > 0 {
+			resumable++
+		}
+		w.EnqueueWait(event)
+	}
+	if len(events) > 0 {
+		log.Printf("Recovered %d unprocessed events (%d with a checkpoint)", len(events), resumable)
+	}
+	return len(events)
+}
+
+// Stop gracefully stops the worker, draining queued events until ctx is
+// done. Events still queued at the deadline are abandoned: they stay
+// accepted in the store for the next Recover, and Stop returns without
+// waiting for the events being processed to finish.
+func (w *Worker) Stop(ctx context.Context) {
+	log.Println("Stopping worker...")
+	w.setState(StateDraining)
+	defer w.setState(StateStopped)
+	w.pool.mu.Lock()
+	w.pool.loops = nil
+	w.pool.mu.Unlock()
+	w.queue.close()
+	// After close, so a scheduler blocked pushing onto a full queue is freed
+	w.stopScheduler()
+	// Runs before the state becomes stopped, once nothing can finish events
+	defer func() {
+		if !w.callbacks.wait(ctx) {
+			log.Println("Shutdown deadline reached, abandoning pending callbacks")
+		}
+	}()
+	if w.mode == ModeManual {
+		w.running.Store(false)
+	} else {
+		// Wait for every processing goroutine to finish draining so a restart
+		// can't race the old ones
+		drained := make(chan struct{})
+		go func() {
+			w.loops.Wait()
+			close(drained)
+		}()
+		select {
+		case <-drained:
+			log.Println("Worker shutting down")
+		case <-ctx.Done():
+			w.abandon.Store(true)
+			log.Printf("Shutdown deadline reached, abandoning %d queued events", w.queue.len())
+			return
+		}
+	}
 
-	go func() {
+	if w.shutdownHandoff {
+		// Queued events are already saved as accepted, so dropping them from
+		// the in-memory queue hands them off to the next Recover
+		handedOff := 0
 		for {
-			select {
-			case event := <-w.queue:
-				w.processEvent(event)
-			case <-w.done:
-				log.Println("Worker shutting down")
-				w.running = false
-				return
+			event, ok := w.queue.tryPop()
+			if !ok {
+				break
 			}
+			w.commits.skip(event)
+			handedOff++
 		}
-	}()
+		log.Printf("Handed off %d queued events to the store", handedOff)
+		return
+	}
+
+	// In manual mode nothing else drains the queue
+	for ctx.Err() == nil {
+		event, ok := w.queue.tryPop()
+		if !ok {
+			break
+		}
+		w.processEvent(event)
+	}
+	if abandoned := w.queue.len(); abandoned > 0 {
+		log.Printf("Shutdown deadline reached, abandoning %d queued events", abandoned)
+	}
+	if held := w.pauser.heldCount(); held > 0 {
+		log.Printf("%d events of paused types were left unprocessed", held)
+	}
 }
 
-// Stop gracefully stops the worker
-func (w *Worker) Stop() {
-	log.Println("Stopping worker...")
-	close(w.done)
-	// Drain remaining events in the queue
-	for len(w.queue) > 0 {
-		event := <-w.queue
+// Tick synchronously processes up to n queued events and returns how many
+// were processed. It is meant for manual mode.
+func (w *Worker) Tick(n int) int {
+	processed := 0
+	for processed < n {
+		event, ok := w.queue.tryPop()
+		if !ok {
+			break
+		}
 		w.processEvent(event)
+		processed++
 	}
+	return processed
 }
 
-// Enqueue adds an event to the processing queue
-func (w *Worker) Enqueue(event *model.Event) {
-	w.queue <- event
+// Mode returns the worker's processing mode
+func (w *Worker) Mode() Mode {
+	return w.mode
+}
+
+// Enqueue adds an event to the processing queue without blocking. It fails
+// with ErrQueueFull if the queue is full, unless the event has a place
+// reserved with ReserveSlot, and with ErrStopped once Stop has begun, until
+// the worker is started again. Scheduled events wait outside the queue until
+// they are due, so only the latter applies to them.
+func (w *Worker) Enqueue(event *model.Event) error {
+	w.activity.touch()
+	if time.Now().Before(event.ProcessAt) {
+		if w.queue.isClosed() {
+			return ErrStopped
+		}
+		w.queue.release(event)
+		w.schedule(event)
+		return nil
+	}
+	w.commits.assign(event)
+	if err := w.queue.tryPush(event); err != nil {
+		w.commits.skip(event)
+		return err
+	}
+	return nil
+}
+
+// EnqueueWait adds an event like Enqueue, but waits for room in a full
+// queue. It is for events that are already stored and must not be dropped,
+// such as retries and recovered events.
+func (w *Worker) EnqueueWait(event *model.Event) {
+	w.activity.touch()
+	if time.Now().Before(event.ProcessAt) {
+		w.queue.release(event)
+		w.schedule(event)
+		return
+	}
+	w.push(event)
+}
+
+// ReserveSlot takes a place in the queue for an event before it is
+// accepted, so it can be enqueued afterwards without finding the queue
+// full. It fails like Enqueue. Enqueueing the event uses up the place;
+// ReleaseSlot gives it back if the event isn't enqueued.
+func (w *Worker) ReserveSlot(event *model.Event) error {
+	return w.queue.reserve(event)
+}
+
+// ReleaseSlot gives back the place reserved for an event, if any
+func (w *Worker) ReleaseSlot(event *model.Event) {
+	w.queue.release(event)
+}
+
+// push hands a due event to the queue, taking its place in the commit order
+func (w *Worker) push(event *model.Event) {
+	w.commits.assign(event)
+	w.queue.push(event)
 }
 
 // IsRunning returns whether the worker is currently running
 func (w *Worker) IsRunning() bool {
-	return w.running
+	if w.mode == ModeManual {
+		return w.running.Load()
+	}
+	return w.alive.Load() > 0
+}
+
+// QueueDepth returns the number of events waiting in the queue
+func (w *Worker) QueueDepth() int {
+	return w.queue.len()
 }
 
-// processEvent simulates event processing with a configurable delay
-func (w *Worker) processEvent(event *model.Event) {
-	log.Printf("Processing event: %s", event.EventID)
+// QueueCapacity returns the number of events the queue can hold
+func (w *Worker) QueueCapacity() int {
+	return w.queue.capacity
+}
+
+// QueueSaturated reports whether the queue has no place left, so events
+// submitted now would be rejected until the worker catches up
+func (w *Worker) QueueSaturated() bool {
+	return w.queue.isFull()
+}
+
+// QueueSaturation returns the fraction of the queue capacity in use, from
+// 0 (empty) to 1 (full)
+func (w *Worker) QueueSaturation() float64 {
+	return float64(w.queue.len()) / float64(w.queue.capacity)
+}
+
+// processEvent runs the event through the processing chain and marks it
+// processed on success. Failed events are retried with exponential backoff
+// and marked failed once out of retries, and events of a paused type are
+// held until the type is resumed. With OrderedCommit the event may be marked
+// processed only after processEvent returns, once its predecessors are.
+// It returns the processing error, if any.
+func (w *Worker) processEvent(event *model.Event) error {
+	w.activity.begin()
+	defer w.activity.end()
+
+	if w.pauser.hold(event) {
+		logEvent(event, "Holding event %s: type %q is paused", event.EventID, event.Type)
+		w.commits.skip(event)
+		return nil
+	}
 
-	// Simulate work
-	time.Sleep(w.processingDelay)
+	attempt, err := w.store.RecordAttempt(event.EventID)
+	if errors.Is(err, store.ErrNotFound) {
+		// Deleted while it was waiting; there is nothing left to process
+		logEvent(event, "Event %s no longer in store, skipping", event.EventID)
+		w.ReleaseBytes(event)
+		w.commits.skip(event)
+		return nil
+	}
+	// Acquired before the span starts so waiting for a slot isn't traced as
+	// processing
+	release := w.acquireSlot()
+	ctx, span := startProcessingSpan(event, attempt)
+	err = w.process(ctx, event)
+	endProcessingSpan(span, err)
+	release()
+	if err != nil {
+		// The in-flight reservation is only kept if the event is re-enqueued
+		if !w.retryOrFail(event, attempt) {
+			w.ReleaseBytes(event)
+			w.commits.skip(event)
+		}
+		return err
+	}
+
+	w.commits.commit(event, func() { w.markProcessed(event) })
+	return nil
+}
+
+// acquireSlot blocks until fewer than MaxInflight events are being
+// processed and returns the func that frees the slot again
+func (w *Worker) acquireSlot() func() {
+	if w.slots == nil {
+		return func() {}
+	}
+	w.slots <- struct{}{}
+	return func() { <-w.slots }
+}
 
-	// Mark as processed
-	w.store.MarkProcessed(event.EventID)
-	log.Printf("Event processed: %s", event.EventID)
+// markProcessed marks a successfully processed event processed, retrying
+// transient store failures
+func (w *Worker) markProcessed(event *model.Event) {
+	// The in-flight reservation is only kept if the event is re-enqueued
+	requeued := false
+	defer func() {
+		if !requeued {
+			w.ReleaseBytes(event)
+		}
+	}()
+
+	err := retryStore(w.storeRetryAttempts, w.storeRetryBackoff, func() error {
+		return w.store.MarkProcessed(event.EventID)
+	})
+	if errors.Is(err, store.ErrNotFound) {
+		logEvent(event, "Event %s no longer in store, skipping status update", event.EventID)
+		return
+	}
+	if err != nil {
+		logEvent(event, "ERROR: failed to mark event %s processed after %d attempts, status update lost: %v", event.EventID, w.storeRetryAttempts, err)
+		if w.storeRetryRequeue {
+			logEvent(event, "Re-enqueueing event %s", event.EventID)
+			requeued = true
+			// Enqueue from a separate goroutine so a full queue can't block
+			// the worker on its own queue
+			go w.EnqueueWait(event)
+		}
+		return
+	}
+	w.recordLatency(event)
+	w.callbacks.notify(event)
+}
+
+// retryOrFail schedules another attempt of a failed event after an
+// exponential backoff, or marks it failed once MaxRetries retries are used
+// up. It returns whether the event was re-enqueued.
+func (w *Worker) retryOrFail(event *model.Event, attempt int) bool {
+	if attempt > w.maxRetries {
+		logEvent(event, "Event %s failed after %d attempts, giving up", event.EventID, attempt)
+		err := retryStore(w.storeRetryAttempts, w.storeRetryBackoff, func() error {
+			return w.store.MarkFailed(event.EventID)
+		})
+		if err != nil {
+			logEvent(event, "ERROR: failed to mark event %s failed: %v", event.EventID, err)
+			return false
+		}
+		w.stats.failed.Add(1)
+		w.callbacks.notify(event)
+		return false
+	}
+
+	delay := retryDelay(attempt, w.retryBackoff, w.retryBackoffMax)
+	if err := w.store.ScheduleRetry(event.EventID, time.Now().Add(delay)); err != nil {
+		logEvent(event, "ERROR: failed to schedule retry of event %s: %v", event.EventID, err)
+		return false
+	}
+	logEvent(event, "Retrying event %s in %v (attempt %d of %d)", event.EventID, delay, attempt+1, w.maxRetries+1)
+	// A retry that is already due goes straight to the queue, which must not
+	// block the worker on its own queue
+	go w.EnqueueWait(event)
+	return true
+}
+
+// simulate simulates event processing with a configurable delay.
+// The work is abandoned if ctx is cancelled first.
+func (w *Worker) simulate(ctx context.Context, event *model.Event) error {
+	timer := time.NewTimer(w.processingDelay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
 }
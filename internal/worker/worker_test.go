@@ -0,0 +1,402 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"event-service/internal/model"
+	"event-service/internal/store"
+	"fmt"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestTickInManualMode(t *testing.T) {
+	st := store.New()
+	w := New(st, Config{Mode: ModeManual})
+	w.Start()
+	defer w.Stop(context.Background())
+
+	for _, id := range []string{"a", "b", "c"} {
+		event := &model.Event{EventID: id, Status: model.StatusAccepted}
+		st.Save(event)
+		w.Enqueue(event)
+	}
+
+	if processed := w.Tick(2); processed != 2 {
+		t.Errorf("Expected 2 events processed, got %d", processed)
+	}
+	if status, _ := st.GetStatus("c"); status != model.StatusAccepted {
+		t.Errorf("Expected c to still be waiting, got %s", status)
+	}
+
+	if processed := w.Tick(5); processed != 1 {
+		t.Errorf("Expected the remaining 1 event processed, got %d", processed)
+	}
+	for _, id := range []string{"a", "b", "c"} {
+		if status, _ := st.GetStatus(id); status != model.StatusProcessed {
+			t.Errorf("Expected %s to be processed, got %s", id, status)
+		}
+	}
+}
+
+func TestPauseType(t *testing.T) {
+	st := store.New()
+	w := New(st, Config{Mode: ModeManual})
+	w.Start()
+	defer w.Stop(context.Background())
+
+	w.PauseType("order")
+	for _, event := range []*model.Event{
+		{EventID: "order_1", Type: "order", Status: model.StatusAccepted},
+		{EventID: "user_1", Type: "user", Status: model.StatusAccepted},
+	} {
+		st.Save(event)
+		w.Enqueue(event)
+	}
+	w.Tick(2)
+
+	if status, _ := st.GetStatus("order_1"); status != model.StatusAccepted {
+		t.Errorf("Expected paused order_1 to be held, got %s", status)
+	}
+	if status, _ := st.GetStatus("user_1"); status != model.StatusProcessed {
+		t.Errorf("Expected user_1 to be processed, got %s", status)
+	}
+	if held := w.PausedTypes()["order"]; held != 1 {
+		t.Errorf("Expected 1 held order event, got %d", held)
+	}
+
+	if released := w.ResumeType("order"); released != 1 {
+		t.Errorf("Expected 1 released event, got %d", released)
+	}
+	for w.queue.len() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	w.Tick(1)
+
+	if status, _ := st.GetStatus("order_1"); status != model.StatusProcessed {
+		t.Errorf("Expected order_1 to be processed after resume, got %s", status)
+	}
+	if len(w.PausedTypes()) != 0 {
+		t.Errorf("Expected no paused types, got %v", w.PausedTypes())
+	}
+}
+
+func TestStats(t *testing.T) {
+	st := store.New()
+	w := New(st, Config{Mode: ModeManual})
+	w.Use(func(next ProcessFunc) ProcessFunc {
+		return func(ctx context.Context, event *model.Event) error {
+			if event.EventID == "bad" {
+				return errors.New("rejected")
+			}
+			return next(ctx, event)
+		}
+	})
+
+	for _, id := range []string{"good", "bad"} {
+		event := &model.Event{EventID: id, Status: model.StatusAccepted}
+		st.Save(event)
+		w.Enqueue(event)
+	}
+	w.Tick(2)
+
+	stats := w.Stats()
+	if stats.Processed != 1 || stats.Failed != 1 {
+		t.Errorf("Expected 1 processed and 1 failed, got %d and %d", stats.Processed, stats.Failed)
+	}
+	if stats.Started != 2 {
+		t.Errorf("Expected 2 started attempts, got %d", stats.Started)
+	}
+	if stats.PeakQueueDepth != 2 {
+		t.Errorf("Expected peak queue depth 2, got %d", stats.PeakQueueDepth)
+	}
+}
+
+func TestIdleFor(t *testing.T) {
+	st := store.New()
+	w := New(st, Config{Mode: ModeManual})
+	w.Start()
+	defer w.Stop(context.Background())
+
+	event := &model.Event{EventID: "a", Status: model.StatusAccepted}
+	st.Save(event)
+	w.Enqueue(event)
+	time.Sleep(10 * time.Millisecond)
+	if idle := w.IdleFor(); idle != 0 {
+		t.Errorf("Expected no idle time with a queued event, got %v", idle)
+	}
+
+	w.Tick(1)
+	time.Sleep(10 * time.Millisecond)
+	if idle := w.IdleFor(); idle < 10*time.Millisecond {
+		t.Errorf("Expected idle time once the queue is empty, got %v", idle)
+	}
+
+	// An event held for a paused type is still work to do
+	w.PauseType("order")
+	held := &model.Event{EventID: "b", Type: "order", Status: model.StatusAccepted}
+	st.Save(held)
+	w.Enqueue(held)
+	w.Tick(1)
+	time.Sleep(10 * time.Millisecond)
+	if idle := w.IdleFor(); idle != 0 {
+		t.Errorf("Expected no idle time with a held event, got %v", idle)
+	}
+}
+
+func TestOnStateChange(t *testing.T) {
+	w := New(store.New(), Config{})
+	states := make(chan State, 10)
+	w.OnStateChange(func(s State) { states <- s })
+
+	// A blocked callback must not stall the worker
+	block := make(chan struct{})
+	defer close(block)
+	w.OnStateChange(func(State) { <-block })
+
+	w.Start()
+	w.Stop(context.Background())
+
+	want := []State{StateStarting, StateRunning, StateDraining, StateStopped}
+	for _, expected := range want {
+		select {
+		case got := <-states:
+			if got != expected {
+				t.Errorf("Expected state %s, got %s", expected, got)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("Timed out waiting for state %s", expected)
+		}
+	}
+	if w.State() != StateStopped {
+		t.Errorf("Expected final state %s, got %s", StateStopped, w.State())
+	}
+}
+
+func TestRestartAfterStop(t *testing.T) {
+	st := store.New()
+	w := New(st, Config{})
+	w.Start()
+	w.Stop(context.Background())
+	if w.IsRunning() {
+		t.Fatal("Expected worker to be stopped")
+	}
+
+	w.Start()
+	defer w.Stop(context.Background())
+	if !w.IsRunning() {
+		t.Fatal("Expected worker to run again after restart")
+	}
+
+	event := &model.Event{EventID: "after_restart", Status: model.StatusAccepted}
+	st.Save(event)
+	w.Enqueue(event)
+
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		if status, _ := st.GetStatus("after_restart"); status == model.StatusProcessed {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Error("Expected the restarted worker to process new events")
+}
+
+func TestEnqueueAfterStop(t *testing.T) {
+	st := store.New()
+	w := New(st, Config{})
+	w.Start()
+	w.Stop(context.Background())
+
+	event := &model.Event{EventID: "after_stop", Status: model.StatusAccepted}
+	st.Save(event)
+	if err := w.Enqueue(event); !errors.Is(err, ErrStopped) {
+		t.Errorf("Expected ErrStopped, got %v", err)
+	}
+	if err := w.ReserveSlot(event); !errors.Is(err, ErrStopped) {
+		t.Errorf("Expected ErrStopped from ReserveSlot, got %v", err)
+	}
+	if status, _ := st.GetStatus("after_stop"); status != model.StatusAccepted {
+		t.Errorf("Expected the event to be left accepted, got %s", status)
+	}
+}
+
+func TestScheduledEvents(t *testing.T) {
+	st := store.New()
+	w := New(st, Config{Mode: ModeManual})
+	w.Start()
+	defer w.Stop(context.Background())
+
+	later := &model.Event{EventID: "later", Status: model.StatusScheduled, ProcessAt: time.Now().Add(time.Hour)}
+	soon := &model.Event{EventID: "soon", Status: model.StatusScheduled, ProcessAt: time.Now().Add(30 * time.Millisecond)}
+	for _, event := range []*model.Event{later, soon} {
+		st.Save(event)
+		w.Enqueue(event)
+	}
+
+	if processed := w.Tick(10); processed != 0 {
+		t.Fatalf("Expected nothing to process before the events are due, got %d", processed)
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	if status, _ := st.GetStatus("soon"); status != model.StatusAccepted {
+		t.Errorf("Expected due event to be accepted again, got %s", status)
+	}
+	if processed := w.Tick(10); processed != 1 {
+		t.Errorf("Expected only the due event to be processed, got %d", processed)
+	}
+	if status, _ := st.GetStatus("later"); status != model.StatusScheduled {
+		t.Errorf("Expected the later event to stay scheduled, got %s", status)
+	}
+}
+
+func TestHighPriorityProcessedFirst(t *testing.T) {
+	st := store.New()
+	w := New(st, Config{Mode: ModeManual})
+	w.Start()
+	defer w.Stop(context.Background())
+
+	low := &model.Event{EventID: "low", Status: model.StatusAccepted}
+	high := &model.Event{EventID: "high", Status: model.StatusAccepted, Priority: 10}
+	for _, event := range []*model.Event{low, high} {
+		st.Save(event)
+		w.Enqueue(event)
+	}
+
+	if processed := w.Tick(1); processed != 1 {
+		t.Fatalf("Expected 1 event processed, got %d", processed)
+	}
+	if status, _ := st.GetStatus("high"); status != model.StatusProcessed {
+		t.Errorf("Expected the high-priority event processed first, got %s", status)
+	}
+	if status, _ := st.GetStatus("low"); status != model.StatusAccepted {
+		t.Errorf("Expected the low-priority event to still wait, got %s", status)
+	}
+}
+
+func TestConcurrency(t *testing.T) {
+	elapsed := func(concurrency int) time.Duration {
+		st := store.New()
+		w := New(st, Config{ProcessingDelayMs: 20, Concurrency: concurrency})
+		start := time.Now()
+		w.Start()
+		for i := 0; i < 10; i++ {
+			event := &model.Event{EventID: fmt.Sprintf("evt_%d", i), Status: model.StatusAccepted}
+			st.Save(event)
+			w.Enqueue(event)
+		}
+		// Stop waits for every worker goroutine to drain the queue
+		w.Stop(context.Background())
+		if w.IsRunning() {
+			t.Errorf("Expected no worker goroutine alive after Stop with concurrency %d", concurrency)
+		}
+		if pending := len(st.ListUnprocessed()); pending != 0 {
+			t.Errorf("Expected all events processed with concurrency %d, got %d pending", concurrency, pending)
+		}
+		return time.Since(start)
+	}
+
+	serial := elapsed(1)
+	parallel := elapsed(5)
+	if parallel >= serial {
+		t.Errorf("Expected concurrency 5 (%v) to be faster than concurrency 1 (%v)", parallel, serial)
+	}
+}
+
+func TestMaxInflight(t *testing.T) {
+	st := store.New()
+	w := New(st, Config{Concurrency: 8, MaxInflight: 2})
+	var current, peak atomic.Int32
+	w.Use(func(next ProcessFunc) ProcessFunc {
+		return func(ctx context.Context, event *model.Event) error {
+			n := current.Add(1)
+			defer current.Add(-1)
+			for {
+				seen := peak.Load()
+				if n <= seen || peak.CompareAndSwap(seen, n) {
+					break
+				}
+			}
+			time.Sleep(20 * time.Millisecond)
+			return next(ctx, event)
+		}
+	})
+
+	w.Start()
+	for i := 0; i < 10; i++ {
+		event := &model.Event{EventID: fmt.Sprintf("evt_%d", i), Status: model.StatusAccepted}
+		st.Save(event)
+		w.Enqueue(event)
+	}
+	w.Stop(context.Background())
+
+	if pending := len(st.ListUnprocessed()); pending != 0 {
+		t.Errorf("Expected all events processed, got %d pending", pending)
+	}
+	if got := peak.Load(); got > 2 {
+		t.Errorf("Expected at most 2 events processed at once, got %d", got)
+	}
+}
+
+func TestShutdownHandoffRecoveredByNextInstance(t *testing.T) {
+	dsn := filepath.Join(t.TempDir(), "events.db")
+	open := func() *store.Store {
+		backend, err := store.OpenSQLite(dsn)
+		if err != nil {
+			t.Fatalf("Failed to open SQLite: %v", err)
+		}
+		st := store.New()
+		if err := st.UseBackend(backend); err != nil {
+			t.Fatalf("Failed to load events: %v", err)
+		}
+		return st
+	}
+
+	st := open()
+	w := New(st, Config{Mode: ModeManual, ShutdownHandoff: true})
+	w.Start()
+	for _, id := range []string{"a", "b", "c"} {
+		event := &model.Event{EventID: id, Status: model.StatusAccepted}
+		st.Save(event)
+		w.Enqueue(event)
+	}
+	w.Tick(1)
+	w.Stop(context.Background())
+	if pending := len(st.ListUnprocessed()); pending != 2 {
+		t.Fatalf("Expected 2 events handed off unprocessed, got %d", pending)
+	}
+	st.Close()
+
+	next := open()
+	defer next.Close()
+	nw := New(next, Config{Mode: ModeManual})
+	nw.Start()
+	defer nw.Stop(context.Background())
+	if recovered := nw.Recover(); recovered != 2 {
+		t.Fatalf("Expected 2 recovered events, got %d", recovered)
+	}
+	nw.Tick(2)
+	for _, id := range []string{"a", "b", "c"} {
+		if status, _ := next.GetStatus(id); status != model.StatusProcessed {
+			t.Errorf("Expected %s processed, got %s", id, status)
+		}
+	}
+}
+
+func TestShutdownHandoffIgnoredWithoutPersistence(t *testing.T) {
+	st := store.New()
+	w := New(st, Config{Mode: ModeManual, ShutdownHandoff: true})
+	w.Start()
+	for _, id := range []string{"a", "b"} {
+		event := &model.Event{EventID: id, Status: model.StatusAccepted}
+		st.Save(event)
+		w.Enqueue(event)
+	}
+	// With nowhere to hand them off to, the queued events are drained
+	w.Stop(context.Background())
+	if pending := len(st.ListUnprocessed()); pending != 0 {
+		t.Errorf("Expected the queue drained, got %d events left unprocessed", pending)
+	}
+}
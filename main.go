@@ -1,11 +1,15 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"log"
+	"net/http"
 	"os"
 	"os/signal"
 	"event-service/internal/app"
 	"syscall"
+	"time"
 )
 
 func main() {
@@ -13,9 +17,15 @@ func main() {
 
 	// Load configuration
 	config := app.LoadConfig()
+	if err := config.Validate(); err != nil {
+		log.Fatal(err)
+	}
 
 	// Create application
-	application := app.New(config)
+	application, err := app.New(config)
+	if err != nil {
+		log.Fatal(err)
+	}
 
 	// Set up signal handling for graceful shutdown
 	sigChan := make(chan os.Signal, 1)
@@ -23,16 +33,24 @@ func main() {
 
 	// Start server in a goroutine
 	go func() {
-		if err := application.Start(); err != nil {
-			log.Printf("Server error: %v", err)
+		// Failing to bind a port is fatal; ErrServerClosed just means
+		// Shutdown was called
+		if err := application.Start(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("Server error: %v", err)
 		}
 	}()
 
-	// Wait for shutdown signal
-	sig := <-sigChan
-	log.Printf("Received signal: %v", sig)
-
-	// Graceful shutdown
-	application.Shutdown()
+	// Wait for a shutdown signal, or for the service to go idle
+	select {
+	case sig := <-sigChan:
+		log.Printf("Received signal: %v", sig)
+	case <-application.Idle():
+		log.Println("Idle timeout reached")
+	}
+
+	// Graceful shutdown, bounded by SHUTDOWN_TIMEOUT_MS
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(config.ShutdownTimeoutMs)*time.Millisecond)
+	defer cancel()
+	application.Shutdown(ctx)
 	log.Println("Service stopped")
 }